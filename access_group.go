@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// accessGroupSyncInterval is how often the members of the bound user groups are synced
+const accessGroupSyncInterval = 10 * time.Minute
+
+// accessGroupSyncLock keep a single sync running, the ticker skip a round while a command sync is running
+var accessGroupSyncLock sync.Mutex
+
+// accessGroupNextSync is only touched by the ticker goroutine
+var accessGroupNextSync time.Time
+
+// userGroupMention is how Slack send a user group mention, <!subteam^S0123|@backend-leads>
+var userGroupMention = regexp.MustCompile(`^<!subteam\^([A-Za-z0-9]+)(?:\|@?([^>]*))?>$`)
+
+var userGroupId = regexp.MustCompile(`^S[A-Z0-9]{6,}$`)
+
+// accessGroupScope is what a grant is unique on
+type accessGroupScope struct {
+	slackId     string
+	project     string
+	environment string
+}
+
+// accessGroupSyncResult is what a sync changed, failed is the groups that could not be read
+type accessGroupSyncResult struct {
+	groups  int
+	granted int
+	revoked int
+	failed  []string
+}
+
+// runAccessGroupSync sync the user groups once per interval in the background, it need the usergroups:read scope
+func runAccessGroupSync(client *slack.Client, now time.Time) {
+	if now.Before(accessGroupNextSync) {
+		return
+	}
+	accessGroupNextSync = now.Add(accessGroupSyncInterval)
+
+	go func() {
+		if !accessGroupSyncLock.TryLock() {
+			return
+		}
+		defer accessGroupSyncLock.Unlock()
+
+		result := syncAccessGroups(client)
+		if result.granted > 0 || result.revoked > 0 || len(result.failed) > 0 {
+			log.Printf("access group sync: %d granted, %d revoked, failed %v", result.granted, result.revoked, result.failed)
+		}
+	}()
+}
+
+// syncAccessGroups make the grants of every binding match the group members. A user in several groups
+// of the same scope get the highest role, a grant given by hand on the scope is never touched, and the
+// grants of a group that cannot be read are kept so a Slack outage does not revoke anyone
+func syncAccessGroups(client *slack.Client) accessGroupSyncResult {
+	var result accessGroupSyncResult
+
+	desired := map[accessGroupScope]models.SlackGroupBinding{}
+	failed := map[string]bool{}
+	for _, binding := range models.GetGroupBindings() {
+		members, err := client.GetUserGroupMembers(binding.GroupId)
+		if err != nil {
+			log.Println("failed to get user group members", binding.GroupId, err.Error())
+			failed[binding.Id] = true
+			result.failed = append(result.failed, "@"+binding.GroupHandle)
+			continue
+		}
+		result.groups++
+		models.SetGroupBindingSynced(binding.Id, len(members))
+
+		for _, member := range members {
+			scope := accessGroupScope{strings.ToUpper(member), binding.Project, binding.Environment}
+			if current, ok := desired[scope]; ok && models.RoleRank(current.Role) >= models.RoleRank(binding.Role) {
+				continue
+			}
+			desired[scope] = binding
+		}
+	}
+
+	existing := map[accessGroupScope]models.SlackUserGrant{}
+	for _, grant := range models.GetGrants() {
+		scope := accessGroupScope{grant.SlackId, grant.Project, grant.Environment}
+		existing[scope] = grant
+
+		if grant.Source == "" || failed[grant.Source] {
+			continue
+		}
+		if _, ok := desired[scope]; !ok {
+			models.DeleteGrantById(grant.Id)
+			auditBot("sync access group", fmt.Sprintf("revoke %s %s on %s in %s", grant.SlackId, grant.Role, grant.Project, grant.Environment))
+			result.revoked++
+		}
+	}
+
+	for scope, binding := range desired {
+		if grant, ok := existing[scope]; ok && (grant.Source == "" || (grant.Source == binding.Id && grant.Role == binding.Role)) {
+			continue
+		}
+		models.SetGroupGrant(scope.slackId, binding.Role, scope.project, scope.environment, "@"+binding.GroupHandle, binding.Id)
+		auditBot("sync access group", fmt.Sprintf("grant %s %s on %s in %s from @%s", scope.slackId, binding.Role, scope.project, scope.environment, binding.GroupHandle))
+		result.granted++
+	}
+
+	return result
+}
+
+// parseUserGroup resolve a user group mention, id or handle to its id and handle
+func parseUserGroup(client *slack.Client, arg string) (string, string, error) {
+	if match := userGroupMention.FindStringSubmatch(arg); match != nil && match[2] != "" {
+		return strings.ToUpper(match[1]), match[2], nil
+	}
+
+	id := ""
+	if match := userGroupMention.FindStringSubmatch(arg); match != nil {
+		id = strings.ToUpper(match[1])
+	} else if userGroupId.MatchString(arg) {
+		id = arg
+	}
+	handle := strings.TrimPrefix(arg, "@")
+
+	groups, err := client.GetUserGroups()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get user groups: %w", err)
+	}
+	for _, group := range groups {
+		if (id != "" && group.ID == id) || (id == "" && strings.EqualFold(group.Handle, handle)) {
+			return group.ID, group.Handle, nil
+		}
+	}
+	return "", "", fmt.Errorf("there is no user group %s", arg)
+}
+
+func accessGroupCommands() []*Command {
+	return []*Command{
+		{
+			Name:        "access group list",
+			Description: "list the user groups bound to a role",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				result := models.GetGroupBindingList(UserLocation(req.User))
+				if result == "" {
+					return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, no user group is bound yet.", req.User.ID)}
+				}
+				return slack.Attachment{Text: fmt.Sprintf("Gotcha <@%s>, this is the user group list: \n\n %s", req.User.ID, result)}
+			},
+		},
+		{
+			Name:        "add access group",
+			Args:        regexp.MustCompile(`^(\S+)\s+(\S+)\s+(\S+)(?:\s+(\S+))?$`),
+			Usage:       "add access group @group role project-name|* [environment|*]",
+			Example:     "add access group @backend-leads releaser logistics-backend production",
+			Description: fmt.Sprintf("give the members of a user group a role on a project, synced every %s, a grant access on the same scope override it", accessGroupSyncInterval),
+			Permission:  PermissionAdmin,
+			Handler:     handleAddAccessGroup,
+		},
+		{
+			Name:        "delete access group",
+			Args:        regexp.MustCompile(`^(\S+)\s+(\S+)(?:\s+(\S+))?$`),
+			Usage:       "delete access group @group project-name|* [environment|*]",
+			Example:     "delete access group @backend-leads logistics-backend production",
+			Description: "unbind a user group, the role it gave is revoked",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				groupId, handle, err := parseUserGroup(req.Client, req.Args[1])
+				if err != nil {
+					return accessGroupNotFound(req, err)
+				}
+				project, environment := strings.ToLower(req.Args[2]), strings.ToLower(req.Args[3])
+				if environment == "" {
+					environment = models.AnyScope
+				}
+				if !models.DeleteGroupBinding(groupId, project, environment) {
+					return slack.Attachment{Text: fmt.Sprintf("Hmm <@%s>, @%s is not bound on project %s in %s", req.User.ID, handle, project, environment)}
+				}
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, @%s is unbound from project %s in %s.", req.User.ID, handle, project, environment)}
+			},
+		},
+		{
+			Name:        "sync access group",
+			Description: "sync the user group members now instead of waiting for the next sync",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				accessGroupSyncLock.Lock()
+				result := syncAccessGroups(req.Client)
+				accessGroupSyncLock.Unlock()
+				return accessGroupSynced(req, result)
+			},
+		},
+	}
+}
+
+func handleAddAccessGroup(req *CommandRequest) slack.Attachment {
+	role, project, environment := strings.ToLower(req.Args[2]), strings.ToLower(req.Args[3]), strings.ToLower(req.Args[4])
+	if models.RoleRank(role) == 0 {
+		return unknownRole(req, role)
+	}
+	if environment == "" {
+		environment = models.AnyScope
+	}
+
+	groupId, handle, err := parseUserGroup(req.Client, req.Args[1])
+	if err != nil {
+		return accessGroupNotFound(req, err)
+	}
+	if !models.AddGroupBinding(groupId, handle, role, project, environment, req.User.Name) {
+		return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, I could not bind @%s, please try again", req.User.ID, handle), Color: "#e20228"}
+	}
+
+	// the members get the role right away
+	accessGroupSyncLock.Lock()
+	result := syncAccessGroups(req.Client)
+	accessGroupSyncLock.Unlock()
+
+	attachment := accessGroupSynced(req, result)
+	attachment.Text = fmt.Sprintf("Roger <@%s>, the members of @%s are now %s on project %s in %s. %s", req.User.ID, handle, role, project, environment, attachment.Text)
+	return attachment
+}
+
+// accessGroupSynced describe a sync, the text is meant to follow another sentence
+func accessGroupSynced(req *CommandRequest, result accessGroupSyncResult) slack.Attachment {
+	attachment := slack.Attachment{
+		Text:  fmt.Sprintf("Synced %d user groups, %d grants given and %d revoked.", result.groups, result.granted, result.revoked),
+		Color: "#4af030",
+	}
+	if len(result.failed) > 0 {
+		attachment.Color = "#e20228"
+		attachment.Footer = fmt.Sprintf("I could not read %s, their members are kept as they were. Check the bot has the usergroups:read scope.", strings.Join(result.failed, ", "))
+	}
+	return attachment
+}
+
+func accessGroupNotFound(req *CommandRequest, err error) slack.Attachment {
+	return slack.Attachment{
+		Text:   fmt.Sprintf("Sorry <@%s>, %s", req.User.ID, err.Error()),
+		Color:  "#e20228",
+		Footer: fmt.Sprintf("GRIP Release Bot cannot continue, '%s'", req.Text),
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// fakeAccessGroups keep the group bindings and the grants in memory and answer the sync queries
+type fakeAccessGroups struct {
+	mu       sync.Mutex
+	bindings []models.SlackGroupBinding
+	grants   []models.SlackUserGrant
+}
+
+func (f *fakeAccessGroups) handle(query string, args []driver.Value) fakeResult {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	arg := func(i int) string { return fmt.Sprint(args[i]) }
+	switch {
+	case strings.Contains(query, "FROM slack_group_binding order by"):
+		var rows [][]driver.Value
+		for _, b := range f.bindings {
+			rows = append(rows, []driver.Value{b.Id, b.GroupId, b.GroupHandle, b.Role, b.Project, b.Environment, b.CreatedBy, int64(b.CreatedAt), int64(b.SyncedAt), int64(b.MemberCount)})
+		}
+		return fakeResult{rows: rows}
+	case strings.HasSuffix(query, "FROM slack_user_grant"):
+		var rows [][]driver.Value
+		for _, g := range f.grants {
+			rows = append(rows, []driver.Value{g.Id, g.SlackId, g.Role, g.Project, g.Environment, g.GrantedBy, int64(g.GrantedAt), g.Source})
+		}
+		return fakeResult{rows: rows}
+	case strings.HasPrefix(query, "DELETE from slack_user_grant where id = ?"):
+		for i, g := range f.grants {
+			if g.Id == arg(0) {
+				f.grants = append(f.grants[:i], f.grants[i+1:]...)
+				return fakeResult{affected: 1}
+			}
+		}
+	case strings.HasPrefix(query, "INSERT INTO slack_user_grant"):
+		grant := models.SlackUserGrant{Id: arg(0), SlackId: arg(1), Role: arg(2), Project: arg(3), Environment: arg(4), GrantedBy: arg(5), Source: arg(7)}
+		for i, g := range f.grants {
+			if g.SlackId == grant.SlackId && g.Project == grant.Project && g.Environment == grant.Environment {
+				// a group grant never replace a grant given by hand
+				if g.Source != "" || !strings.Contains(query, "IF(source = ''") {
+					grant.Id = g.Id
+					f.grants[i] = grant
+				}
+				return fakeResult{affected: 2}
+			}
+		}
+		f.grants = append(f.grants, grant)
+		return fakeResult{affected: 1}
+	}
+	return fakeResult{}
+}
+
+// summary list the grants as slack id:role@project/environment<source, sorted
+func (f *fakeAccessGroups) summary() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var lines []string
+	for _, g := range f.grants {
+		lines = append(lines, fmt.Sprintf("%s:%s@%s/%s<%s", g.SlackId, g.Role, g.Project, g.Environment, g.Source))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, " ")
+}
+
+// newFakeUserGroups answer usergroups.users.list with members, a group missing from members cannot be read,
+// and usergroups.list with the qa and backend-leads groups
+func newFakeUserGroups(t *testing.T, members map[string][]string) *slack.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/usergroups.list" {
+			fmt.Fprint(w, `{"ok":true,"usergroups":[{"id":"SQA0001","handle":"qa"},{"id":"SLEADS01","handle":"backend-leads"}]}`)
+			return
+		}
+		users, ok := members[r.Form.Get("usergroup")]
+		if r.URL.Path != "/usergroups.users.list" || !ok {
+			fmt.Fprint(w, `{"ok":false,"error":"missing_scope"}`)
+			return
+		}
+		body, _ := json.Marshal(map[string]any{"ok": true, "users": users})
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return slack.New("xoxb-test", slack.OptionAPIURL(server.URL+"/"))
+}
+
+func TestSyncAccessGroups(t *testing.T) {
+	qa := models.SlackGroupBinding{Id: "b-qa", GroupId: "SQA0001", GroupHandle: "qa", Role: models.RoleSandbox, Project: "grip", Environment: "*"}
+	leads := models.SlackGroupBinding{Id: "b-leads", GroupId: "SLEADS01", GroupHandle: "leads", Role: models.RoleReleaser, Project: "grip", Environment: "*"}
+	byHand := func(slackId string, role string) models.SlackUserGrant {
+		return models.SlackUserGrant{Id: "g-" + slackId, SlackId: slackId, Role: role, Project: "grip", Environment: "*", GrantedBy: "admin"}
+	}
+	synced := func(slackId string, binding models.SlackGroupBinding, role string) models.SlackUserGrant {
+		return models.SlackUserGrant{Id: "g-" + slackId, SlackId: slackId, Role: role, Project: "grip", Environment: "*", GrantedBy: "@" + binding.GroupHandle, Source: binding.Id}
+	}
+
+	tests := []struct {
+		name     string
+		bindings []models.SlackGroupBinding
+		grants   []models.SlackUserGrant
+		members  map[string][]string
+		want     string
+		granted  int
+		revoked  int
+		failed   string
+	}{
+		{
+			name:     "members are granted",
+			bindings: []models.SlackGroupBinding{qa},
+			members:  map[string][]string{"SQA0001": {"U0ALICE", "u0bob"}},
+			want:     "U0ALICE:sandbox@grip/*<b-qa U0BOB:sandbox@grip/*<b-qa",
+			granted:  2,
+		},
+		{
+			name:     "highest role of several groups",
+			bindings: []models.SlackGroupBinding{leads, qa},
+			members:  map[string][]string{"SQA0001": {"U0ALICE", "U0BOB"}, "SLEADS01": {"U0ALICE"}},
+			want:     "U0ALICE:releaser@grip/*<b-leads U0BOB:sandbox@grip/*<b-qa",
+			granted:  2,
+		},
+		{
+			name:     "already in sync",
+			bindings: []models.SlackGroupBinding{leads, qa},
+			grants:   []models.SlackUserGrant{synced("U0ALICE", leads, models.RoleReleaser)},
+			members:  map[string][]string{"SQA0001": {"U0ALICE"}, "SLEADS01": {"U0ALICE"}},
+			want:     "U0ALICE:releaser@grip/*<b-leads",
+		},
+		{
+			name:     "binding role changed",
+			bindings: []models.SlackGroupBinding{qa},
+			grants:   []models.SlackUserGrant{synced("U0ALICE", qa, models.RoleReleaser)},
+			members:  map[string][]string{"SQA0001": {"U0ALICE"}},
+			want:     "U0ALICE:sandbox@grip/*<b-qa",
+			granted:  1,
+		},
+		{
+			name:     "member left the group",
+			bindings: []models.SlackGroupBinding{qa},
+			grants:   []models.SlackUserGrant{synced("U0ALICE", qa, models.RoleSandbox), synced("U0BOB", qa, models.RoleSandbox)},
+			members:  map[string][]string{"SQA0001": {"U0ALICE"}},
+			want:     "U0ALICE:sandbox@grip/*<b-qa",
+			revoked:  1,
+		},
+		{
+			name:     "grant by hand override the group",
+			bindings: []models.SlackGroupBinding{leads},
+			grants:   []models.SlackUserGrant{byHand("U0ALICE", models.RoleViewer)},
+			members:  map[string][]string{"SLEADS01": {"U0ALICE"}},
+			want:     "U0ALICE:viewer@grip/*<",
+		},
+		{
+			name:   "grant by hand is kept without a group",
+			grants: []models.SlackUserGrant{byHand("U0ALICE", models.RoleAdmin)},
+			want:   "U0ALICE:admin@grip/*<",
+		},
+		{
+			name:     "grants of a group that cannot be read are kept",
+			bindings: []models.SlackGroupBinding{leads, qa},
+			grants:   []models.SlackUserGrant{synced("U0ALICE", qa, models.RoleSandbox), synced("U0BOB", leads, models.RoleReleaser)},
+			members:  map[string][]string{"SLEADS01": {}},
+			want:     "U0ALICE:sandbox@grip/*<b-qa",
+			revoked:  1,
+			failed:   "@qa",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			groups := &fakeAccessGroups{bindings: test.bindings, grants: test.grants}
+			useFakeDB(t, groups.handle)
+
+			result := syncAccessGroups(newFakeUserGroups(t, test.members))
+
+			if got := groups.summary(); got != test.want {
+				t.Errorf("grants = %q, want %q", got, test.want)
+			}
+			if result.granted != test.granted || result.revoked != test.revoked || strings.Join(result.failed, ",") != test.failed {
+				t.Errorf("result = %+v, want %d granted, %d revoked, failed %q", result, test.granted, test.revoked, test.failed)
+			}
+		})
+	}
+}
+
+func TestParseUserGroup(t *testing.T) {
+	tests := []struct {
+		arg    string
+		id     string
+		handle string
+	}{
+		{"<!subteam^S0NOTLISTED|@platform>", "S0NOTLISTED", "platform"},
+		{"<!subteam^sleads01|backend-leads>", "SLEADS01", "backend-leads"},
+		{"<!subteam^SLEADS01>", "SLEADS01", "backend-leads"},
+		{"SQA0001", "SQA0001", "qa"},
+		{"@Backend-Leads", "SLEADS01", "backend-leads"},
+		{"qa", "SQA0001", "qa"},
+		{"@platform", "", ""},
+		{"<!subteam^S0NOTLISTED>", "", ""},
+	}
+
+	client := newFakeUserGroups(t, nil)
+	for _, test := range tests {
+		id, handle, err := parseUserGroup(client, test.arg)
+		if id != test.id || handle != test.handle || (err != nil) != (test.id == "") {
+			t.Errorf("parseUserGroup(%q) = %q, %q, %v, want %q, %q", test.arg, id, handle, err, test.id, test.handle)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// block action id of the cancel button next to an upcoming release on the Home tab
+const actionHomeCancelSchedule = "home_cancel_schedule"
+
+// number of rows shown per section, a Home tab is limited to 100 blocks
+const (
+	homeUpcomingLimit = 20
+	homeRecentLimit   = 10
+)
+
+// releaseStateEmoji prefix the recent release results on the Home tab
+var releaseStateEmoji = map[string]string{
+	models.ReleaseFiring:    ":hourglass_flowing_sand:",
+	models.ReleaseTriggered: ":arrow_forward:",
+	models.ReleaseSucceeded: ":white_check_mark:",
+	models.ReleaseFailed:    ":x:",
+	models.ReleaseCancelled: ":no_entry_sign:",
+	models.ReleaseExpired:   ":hourglass:",
+}
+
+// publishAppHome render the release and sandbox dashboard on the Home tab of userId
+func publishAppHome(client *slack.Client, userId string) error {
+	user, err := client.GetUserInfo(userId)
+	if err != nil {
+		return fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	view := slack.HomeTabViewRequest{
+		Type:   slack.VTHomeTab,
+		Blocks: slack.Blocks{BlockSet: appHomeBlocks(user)},
+	}
+	if _, err := client.PublishView(userId, view, ""); err != nil {
+		return fmt.Errorf("failed to publish app home: %w", err)
+	}
+	return nil
+}
+
+func appHomeBlocks(user *slack.User) []slack.Block {
+	location := UserLocation(user)
+	// the release permission is per project, keep the answer for the projects already checked
+	canCancel := map[string]bool{}
+
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "Upcoming releases", false, false)),
+	}
+	upcoming := models.GetPendingSchedules(homeUpcomingLimit)
+	if len(upcoming) == 0 {
+		blocks = append(blocks, homeText("No release is scheduled."))
+	}
+	for _, releaseSchedule := range upcoming {
+		section := homeText(fmt.Sprintf("*%s* > %s\n%s, created by %s", releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion, models.FormatTime(releaseSchedule.ReleaseOn, location), releaseSchedule.CreatedBy))
+		allowed, checked := canCancel[releaseSchedule.ReleaseProject]
+		if !checked {
+			allowed = PermissionRelease.AllowsProject(user.ID, releaseSchedule.ReleaseProject)
+			canCancel[releaseSchedule.ReleaseProject] = allowed
+		}
+		if allowed {
+			section.Accessory = slack.NewAccessory(
+				slack.NewButtonBlockElement(actionHomeCancelSchedule, releaseSchedule.Id, slack.NewTextBlockObject(slack.PlainTextType, "Cancel", false, false)).
+					WithStyle(slack.StyleDanger).
+					WithConfirm(slack.NewConfirmationBlockObject(
+						slack.NewTextBlockObject(slack.PlainTextType, "Cancel release?", false, false),
+						slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("Cancel the release of *%s* version %s?", releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion), false, false),
+						slack.NewTextBlockObject(slack.PlainTextType, "Cancel release", false, false),
+						slack.NewTextBlockObject(slack.PlainTextType, "Keep", false, false),
+					)),
+			)
+		}
+		blocks = append(blocks, section)
+	}
+
+	blocks = append(blocks,
+		slack.NewDividerBlock(),
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "Recent releases", false, false)),
+	)
+	var recent []string
+	for _, releaseSchedule := range models.GetRecentReleases(homeRecentLimit) {
+		recent = append(recent, fmt.Sprintf("%s *%s* > %s is *%s*, %s", releaseStateEmoji[releaseSchedule.State], releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion, releaseSchedule.State, models.FormatTime(releaseSchedule.StateChangedAt, location)))
+	}
+	if len(recent) == 0 {
+		recent = append(recent, "No release yet.")
+	}
+	blocks = append(blocks, homeText(strings.Join(recent, "\n")))
+
+	blocks = append(blocks,
+		slack.NewDividerBlock(),
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "Projects", false, false)),
+	)
+	projects := models.GetEnabledProjectNames()
+	if len(projects) == 0 {
+		blocks = append(blocks, homeText("No enabled project."))
+	} else {
+		blocks = append(blocks, homeText(strings.Join(projects, ", ")))
+	}
+
+	blocks = append(blocks,
+		slack.NewDividerBlock(),
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "Sandbox servers", false, false)),
+	)
+	servers := models.GetAllServers()
+	if len(servers) == 0 {
+		blocks = append(blocks, homeText("No sandbox server."))
+	}
+	// one section per project keep the Home tab under the block limit
+	var lines []string
+	for i, server := range servers {
+		inUse := ""
+		if !server.Enabled {
+			inUse = " :no_entry: disabled"
+		} else if server.Status {
+			inUse = fmt.Sprintf(" :lock: in use by %s", server.StatusChangedBy)
+		}
+		if server.Enabled && server.HealthURL != "" && server.HealthCheckedAt > 0 && !server.HealthUp {
+			inUse += " :red_circle: down"
+		}
+		lines = append(lines, fmt.Sprintf("*%s*%s, last build by *%s* (%s)\n\t FE: %s, BE: %s", server.ServerId, inUse, server.LastBuildBy, models.FormatTime(server.LastBuildOn, location), server.LastFeBranch, server.LastBeBranch))
+		if i == len(servers)-1 || servers[i+1].Project != server.Project {
+			blocks = append(blocks, homeText(fmt.Sprintf("*%s*\n%s", server.Project, strings.Join(lines, "\n"))))
+			lines = nil
+		}
+	}
+
+	return append(blocks, slack.NewContextBlock("",
+		slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("Times are in %s. Mention me with `help` to see every command.", location.String()), false, false),
+	))
+}
+
+func homeText(text string) *slack.SectionBlock {
+	return slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)
+}
+
+// handleHomeCancelSchedule cancel an upcoming release from the Home tab and refresh it
+func handleHomeCancelSchedule(callback slack.InteractionCallback, action *slack.BlockAction, client *slack.Client) error {
+	releaseSchedule, ok := models.GetSchedule(action.Value)
+	if ok && PermissionRelease.AllowsProject(callback.User.ID, releaseSchedule.ReleaseProject) && models.TransitionRelease(releaseSchedule.Id, models.ReleasePending, models.ReleaseCancelled, callback.User.Name, "cancelled from the app home") {
+		notifyRelease(client, releaseSchedule, slack.Attachment{
+			Text:   fmt.Sprintf("Release of %s version %s is cancelled by <@%s>", releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion, callback.User.ID),
+			Color:  "#563a9b",
+			Footer: fmt.Sprintf("Release Id: %s", releaseSchedule.Id),
+		})
+	}
+
+	return publishAppHome(client, callback.User.ID)
+}
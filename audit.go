@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// number of audit entries shown in Slack and put in an export
+const (
+	auditShowLimit   = 20
+	auditExportLimit = 10000
+)
+
+// auditSince match the since:YYYY-MM-DD word of an audit filter
+var auditSince = regexp.MustCompile(`(?i)^since:(\d{4}-\d{2}-\d{2})$`)
+
+// auditCommand record a command run from a mention or a slash command, the Secret submatches
+// are redacted, and the whole arguments when they did not match so a mistyped secret is not kept
+func auditCommand(req *CommandRequest, command *Command, args string, outcome string) {
+	arguments := args
+	if len(command.Secret) > 0 {
+		if outcome == models.AuditInvalid {
+			arguments = models.AuditRedacted
+		} else {
+			for _, i := range command.Secret {
+				if i < len(req.Args) && req.Args[i] != "" {
+					arguments = strings.ReplaceAll(arguments, req.Args[i], models.AuditRedacted)
+				}
+			}
+		}
+	}
+
+	models.AddAudit(models.AuditLog{
+		ActorId:   req.User.ID,
+		ActorName: req.User.Name,
+		Command:   command.Name,
+		Arguments: arguments,
+		Channel:   req.Channel,
+		Outcome:   outcome,
+	})
+}
+
+// auditInteraction record a button click, a menu pick or a modal submit
+func auditInteraction(callback slack.InteractionCallback, command string, arguments string, err error) {
+	outcome := models.AuditOk
+	if err != nil {
+		outcome = models.AuditFailed
+	}
+	models.AddAudit(models.AuditLog{
+		ActorId:   callback.User.ID,
+		ActorName: callback.User.Name,
+		Command:   command,
+		Arguments: arguments,
+		Channel:   callback.Channel.ID,
+		Outcome:   outcome,
+	})
+}
+
+// auditBot record a change the bot did by itself, e.g. an expired claim
+func auditBot(command string, arguments string) {
+	models.AddAudit(models.AuditLog{ActorName: models.BotActor, Command: command, Arguments: arguments, Outcome: models.AuditOk})
+}
+
+// actionValue is the value of a block action whatever its element type
+func actionValue(action slack.BlockAction) string {
+	switch {
+	case action.SelectedOption.Value != "":
+		return action.SelectedOption.Value
+	case action.SelectedDate != "":
+		return action.SelectedDate
+	case action.SelectedTime != "":
+		return action.SelectedTime
+	case action.SelectedConversation != "":
+		return action.SelectedConversation
+	}
+	return action.Value
+}
+
+// viewValues flatten the inputs of a submitted modal, e.g. schedule_project=logistics schedule_version=1.2
+func viewValues(view slack.View) string {
+	if view.State == nil {
+		return ""
+	}
+	var values []string
+	for _, actions := range view.State.Values {
+		for actionId, action := range actions {
+			values = append(values, fmt.Sprintf("%s=%s", actionId, actionValue(action)))
+		}
+	}
+	// map order is random, keep the audit stable
+	sort.Strings(values)
+	return strings.Join(values, " ")
+}
+
+func auditCommands() []*Command {
+	return []*Command{
+		{
+			Name:        "audit",
+			Usage:       "audit [@user] [since:YYYY-MM-DD] [text]",
+			Example:     "audit @steven since:2026-10-01 delete project",
+			Description: fmt.Sprintf("show the last %d commands and changes matching the filter", auditShowLimit),
+			Permission:  PermissionAdmin,
+			Handler:     handleAudit,
+		},
+		{
+			Name:        "audit export",
+			Usage:       "audit export [@user] [since:YYYY-MM-DD] [text]",
+			Example:     "audit export since:2026-10-01",
+			Description: fmt.Sprintf("upload the audit log matching the filter as a CSV file, up to %d entries", auditExportLimit),
+			Permission:  PermissionAdmin,
+			Handler:     handleAuditExport,
+		},
+	}
+}
+
+// parseAuditFilter read the user, since and free text words of an audit filter
+func parseAuditFilter(text string, location *time.Location) (models.AuditFilter, error) {
+	var filter models.AuditFilter
+	var words []string
+	for _, word := range strings.Fields(text) {
+		if match := auditSince.FindStringSubmatch(word); match != nil {
+			since, err := time.ParseInLocation("2006-01-02", match[1], location)
+			if err != nil {
+				return filter, fmt.Errorf("the date %s is not valid", match[1])
+			}
+			filter.Since = since
+			continue
+		}
+		if slackIdMention.MatchString(word) || slackUserId.MatchString(word) {
+			filter.ActorId = parseSlackId(word)
+			continue
+		}
+		words = append(words, word)
+	}
+	filter.Text = strings.Join(words, " ")
+	return filter, nil
+}
+
+func handleAudit(req *CommandRequest) slack.Attachment {
+	location := UserLocation(req.User)
+	filter, err := parseAuditFilter(req.Args[0], location)
+	if err != nil {
+		return invalidAuditFilter(req, err)
+	}
+
+	entries := models.GetAuditLog(filter, auditShowLimit)
+	if len(entries) == 0 {
+		return slack.Attachment{Text: fmt.Sprintf("Woah <@%s>, nothing in the audit log match '%s'", req.User.ID, req.Args[0]), Color: "#563a9b"}
+	}
+
+	var lines []string
+	for _, entry := range entries {
+		actor := entry.ActorName
+		if entry.ActorId != "" {
+			actor = fmt.Sprintf("<@%s>", entry.ActorId)
+		}
+		line := fmt.Sprintf("%s %s `%s %s` *%s*", models.FormatTime(entry.CreatedAt, location), actor, entry.Command, entry.Arguments, entry.Outcome)
+		if entry.Channel != "" {
+			line += fmt.Sprintf(" in <#%s>", entry.Channel)
+		}
+		lines = append(lines, line)
+	}
+	return slack.Attachment{
+		Text:   fmt.Sprintf("Here you go <@%s>, the last %d audit entries: \n\n%s", req.User.ID, len(entries), strings.Join(lines, "\n")),
+		Color:  "#563a9b",
+		Footer: "Use audit export to get everything as a CSV file.",
+	}
+}
+
+func handleAuditExport(req *CommandRequest) slack.Attachment {
+	filter, err := parseAuditFilter(req.Args[0], UserLocation(req.User))
+	if err != nil {
+		return invalidAuditFilter(req, err)
+	}
+
+	entries := models.GetAuditLog(filter, auditExportLimit)
+	if len(entries) == 0 {
+		return slack.Attachment{Text: fmt.Sprintf("Woah <@%s>, nothing in the audit log match '%s'", req.User.ID, req.Args[0]), Color: "#563a9b"}
+	}
+
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	writer.Write([]string{"created_at", "actor_id", "actor_name", "command", "arguments", "channel", "outcome"})
+	for _, entry := range entries {
+		writer.Write([]string{time.Unix(int64(entry.CreatedAt), 0).UTC().Format(time.RFC3339), entry.ActorId, entry.ActorName, entry.Command, entry.Arguments, entry.Channel, entry.Outcome})
+	}
+	writer.Flush()
+
+	_, err = req.Client.UploadFileV2(slack.UploadFileV2Parameters{
+		Reader:          &buffer,
+		FileSize:        buffer.Len(),
+		Filename:        fmt.Sprintf("audit-%s.csv", time.Now().UTC().Format("20060102-150405")),
+		Title:           "Audit log",
+		InitialComment:  fmt.Sprintf("Here you go <@%s>, %d audit entries, times are in UTC.", req.User.ID, len(entries)),
+		Channel:         req.Channel,
+		ThreadTimestamp: req.ThreadTs,
+	})
+	if err != nil {
+		return slack.Attachment{
+			Text:   fmt.Sprintf("Sorry <@%s>, I could not upload the audit log: %s", req.User.ID, err.Error()),
+			Color:  "#e20228",
+			Footer: "GRIP Release Bot cannot continue, the bot need the files:write scope",
+		}
+	}
+	// the file comment is the reply
+	return slack.Attachment{}
+}
+
+func invalidAuditFilter(req *CommandRequest, err error) slack.Attachment {
+	return slack.Attachment{
+		Text:   fmt.Sprintf("Sorry <@%s>, %s, use since:YYYY-MM-DD", req.User.ID, err.Error()),
+		Color:  "#e20228",
+		Footer: fmt.Sprintf("GRIP Release Bot cannot continue, '%s'", req.Text),
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// CommandRequest is everything a command handler need to build the reply
+type CommandRequest struct {
+	Client  *slack.Client
+	User    *slack.User
+	Channel string
+	// ThreadTs is the thread follow up message about the command should go to
+	ThreadTs string
+	// Text is the full message without the bot mention
+	Text string
+	// Args is the submatches of the command Args grammar, Args[0] is the whole argument string
+	Args []string
+	// Post send a message where the command came from and return its timestamp,
+	// handler that post their own message, e.g. with blocks, return an empty attachment
+	Post func(options ...slack.MsgOption) (string, error)
+	// denied is set by permissionDenied so the audit log tell a denial from a failure
+	denied bool
+}
+
+// Command define a single bot command
+type Command struct {
+	// Name is the keywords the message must start with, e.g. "schedule release"
+	Name string
+	// Args is the grammar of the text after Name, nil means arguments are ignored
+	Args *regexp.Regexp
+	// Description is the one line summary shown in help
+	Description string
+	// Usage is shown in help and when the arguments do not match Args, default to Name
+	Usage string
+	// Example is a sample message shown in help
+	Example    string
+	Permission Permission
+	// Secret is the Args submatch indexes that are redacted in the audit log, e.g. a token
+	Secret []int
+	// Private reply only to the caller from /release, e.g. when the reply show a token
+	Private bool
+	// Handler build the reply, an attachment without text means the handler already replied with req.Post
+	Handler func(req *CommandRequest) slack.Attachment
+}
+
+// CommandRegistry hold the commands and find which one a message is for
+type CommandRegistry struct {
+	commands []*Command
+}
+
+func NewCommandRegistry(commands ...*Command) *CommandRegistry {
+	registry := &CommandRegistry{}
+	for _, command := range commands {
+		registry.Register(command)
+	}
+	return registry
+}
+
+// Register add a command, it panics on duplicate name since that is a programming error
+func (r *CommandRegistry) Register(command *Command) {
+	for _, existing := range r.commands {
+		if strings.EqualFold(existing.Name, command.Name) {
+			panic(fmt.Sprintf("command %q registered twice", command.Name))
+		}
+	}
+	r.commands = append(r.commands, command)
+}
+
+// Commands return the registered commands in registration order
+func (r *CommandRegistry) Commands() []*Command {
+	return r.commands
+}
+
+// Allowed return the commands the user can run, permissions are checked once per level
+func (r *CommandRegistry) Allowed(SlackId string) []*Command {
+	allowed := map[Permission]bool{}
+	var commands []*Command
+	for _, command := range r.commands {
+		ok, checked := allowed[command.Permission]
+		if !checked {
+			ok = command.Permission.Allows(SlackId)
+			allowed[command.Permission] = ok
+		}
+		if ok {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+// UsageText return the usage or the name when the command has no arguments
+func (c *Command) UsageText() string {
+	if c.Usage == "" {
+		return c.Name
+	}
+	return c.Usage
+}
+
+// Match return the command whose name is the longest word prefix of text and the remaining argument string as typed
+func (r *CommandRegistry) Match(text string) (*Command, string) {
+	words := strings.Fields(text)
+
+	var matched *Command
+	matchedLen := 0
+	for _, command := range r.commands {
+		name := strings.Fields(command.Name)
+		if len(name) <= matchedLen || len(name) > len(words) {
+			continue
+		}
+
+		ok := true
+		for i := range name {
+			if !strings.EqualFold(name[i], words[i]) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matched = command
+			matchedLen = len(name)
+		}
+	}
+
+	if matched == nil {
+		return nil, ""
+	}
+
+	return matched, afterWords(text, matchedLen)
+}
+
+// afterWords return text after its first n words with the spacing kept as typed, so the arguments
+// like a JSON config or a webhook template reach the command unchanged
+func afterWords(text string, n int) string {
+	rest := strings.TrimLeftFunc(text, unicode.IsSpace)
+	for i := 0; i < n; i++ {
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			return ""
+		}
+		rest = strings.TrimLeftFunc(rest[end:], unicode.IsSpace)
+	}
+	return strings.TrimRightFunc(rest, unicode.IsSpace)
+}
+
+// Dispatch run the matching command for text, the bool is false when no command match.
+// Every matched command is recorded in the audit log
+func (r *CommandRegistry) Dispatch(req *CommandRequest) (slack.Attachment, bool) {
+	command, args := r.Match(req.Text)
+	if command == nil {
+		return slack.Attachment{}, false
+	}
+
+	attachment, outcome := r.run(req, command, args)
+	auditCommand(req, command, args, outcome)
+	return attachment, true
+}
+
+func (r *CommandRegistry) run(req *CommandRequest, command *Command, args string) (slack.Attachment, string) {
+	if !command.Permission.Allows(req.User.ID) {
+		return permissionDenied(req, ""), models.AuditDenied
+	}
+
+	req.Args = []string{args}
+	if command.Args != nil {
+		match := command.Args.FindStringSubmatch(args)
+		if match == nil {
+			attachment := slack.Attachment{
+				Text:   fmt.Sprintf("Sorry <@%s>, make sure the format is '%s'.", req.User.ID, command.UsageText()),
+				Color:  "#e20228",
+				Footer: fmt.Sprintf("GRIP Release Bot cannot continue, '%s'", req.Text),
+			}
+			if command.Example != "" {
+				attachment.Footer = fmt.Sprintf("Example: %s", command.Example)
+			}
+			return attachment, models.AuditInvalid
+		}
+		for i := range match {
+			match[i] = strings.TrimSpace(match[i])
+		}
+		req.Args = match
+	}
+
+	attachment := command.Handler(req)
+	if req.denied {
+		return attachment, models.AuditDenied
+	}
+	if attachment.Color == "#e20228" {
+		return attachment, models.AuditFailed
+	}
+	return attachment, models.AuditOk
+}
+
+var channelMention = regexp.MustCompile(`^<#([A-Za-z0-9]+)(\|[^>]*)?>$`)
+
+var channelId = regexp.MustCompile(`^[CG][A-Z0-9]{6,}$`)
+
+// parseChannelId accept a <#C123|name> channel mention or a raw channel id, empty when it is neither
+func parseChannelId(arg string) string {
+	if match := channelMention.FindStringSubmatch(arg); match != nil {
+		return strings.ToUpper(match[1])
+	}
+	if channelId.MatchString(arg) {
+		return arg
+	}
+	return ""
+}
+
+var slackIdMention = regexp.MustCompile(`^<@([A-Za-z0-9]+)(\|[^>]*)?>$`)
+
+// parseSlackId accept both a raw slack id and a <@U123|name> mention
+func parseSlackId(arg string) string {
+	if match := slackIdMention.FindStringSubmatch(arg); match != nil {
+		return strings.ToUpper(match[1])
+	}
+	return strings.ToUpper(arg)
+}
+
+var slackLink = regexp.MustCompile(`<((?:https?|mailto):[^|>]+)(?:\|([^>]*))?>`)
+
+var smartQuotes = strings.NewReplacer("“", `"`, "”", `"`, "‘", "'", "’", "'")
+
+// normalizeText undo the formatting Slack add to what the user typed, links like
+// <http://jenkins.host|jenkins.host> become the typed text again and smart quotes become plain quotes
+func normalizeText(text string) string {
+	text = slackLink.ReplaceAllStringFunc(text, func(link string) string {
+		match := slackLink.FindStringSubmatch(link)
+		if match[2] != "" {
+			return match[2]
+		}
+		return match[1]
+	})
+	return smartQuotes.Replace(text)
+}
+
+var mentionPrefix = regexp.MustCompile(`^(\s*<@[A-Za-z0-9]+>)+\s*`)
+
+// stripMention remove the leading bot mention from an app_mention text
+func stripMention(text string) string {
+	return strings.TrimSpace(mentionPrefix.ReplaceAllString(text, ""))
+}
+
+// botCommands is the registry used by the mention and slash command handlers
+var botCommands *CommandRegistry
+
+func init() {
+	var commands []*Command
+	commands = append(commands, generalCommands()...)
+	commands = append(commands, releaseCommands()...)
+	commands = append(commands, accessCommands()...)
+	commands = append(commands, accessGroupCommands()...)
+	commands = append(commands, auditCommands()...)
+	commands = append(commands, projectCommands()...)
+	commands = append(commands, sandboxCommands()...)
+	commands = append(commands, sandboxAdminCommands()...)
+
+	botCommands = NewCommandRegistry(commands...)
+}
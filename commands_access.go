@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+func accessCommands() []*Command {
+	return []*Command{
+		{
+			Name:        "access list",
+			Description: "list the users that have access",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				result := models.GetAllUsers()
+				if result == "" {
+					return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, access list is empty.", req.User.ID)}
+				}
+				return slack.Attachment{Text: fmt.Sprintf("Gotcha <@%s>, this is the access list: \n\n %s", req.User.ID, result)}
+			},
+		},
+		{
+			Name:        "add access",
+			Args:        regexp.MustCompile(`^([^-\s]+)-(.+)$`),
+			Usage:       "add access SLACKID-name",
+			Example:     "add access U023A0BJUB1-Steven",
+			Description: "give a user access to the bot with the releaser role",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				models.AddNewUser(parseSlackId(req.Args[1]), req.Args[2])
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, Adding access for %s.", req.User.ID, req.Args[2])}
+			},
+		},
+		{
+			Name:        "delete access",
+			Args:        regexp.MustCompile(`^(\S+)$`),
+			Usage:       "delete access SLACKID",
+			Description: "remove a user access",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				models.DeleteUserAccess(parseSlackId(req.Args[1]))
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, Removing access for %s.", req.User.ID, parseSlackId(req.Args[1]))}
+			},
+		},
+		{
+			Name:        "enable access",
+			Args:        regexp.MustCompile(`^(\S+)$`),
+			Usage:       "enable access SLACKID",
+			Description: "enable a disabled user access",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				if !models.ToogleUserStatus(parseSlackId(req.Args[1]), true) {
+					return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, %s is not in the access list, use add access first.", req.User.ID, parseSlackId(req.Args[1])), Color: "#e20228"}
+				}
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, Enabling access for %s.", req.User.ID, parseSlackId(req.Args[1]))}
+			},
+		},
+		{
+			Name:        "disable access",
+			Args:        regexp.MustCompile(`^(\S+)$`),
+			Usage:       "disable access SLACKID",
+			Description: "disable a user access without removing it, it also block the roles given by a user group",
+			Permission:  PermissionAdmin,
+			Handler:     handleDisableAccess,
+		},
+		{
+			Name:        "set access role",
+			Args:        regexp.MustCompile(`^(\S+)\s+(\S+)$`),
+			Usage:       "set access role SLACKID viewer|sandbox|releaser|admin",
+			Example:     "set access role U023A0BJUB1 sandbox",
+			Description: "change the role of a user on every project",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				slackId, role := parseSlackId(req.Args[1]), strings.ToLower(req.Args[2])
+				if models.RoleRank(role) == 0 {
+					return unknownRole(req, role)
+				}
+				if !models.SetUserRole(slackId, role) {
+					return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, %s is not in the access list, use add access first.", req.User.ID, slackId), Color: "#e20228"}
+				}
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, %s is now %s.", req.User.ID, slackId, role)}
+			},
+		},
+		{
+			Name:        "grant access",
+			Args:        regexp.MustCompile(`^(\S+)\s+(\S+)\s+(\S+)(?:\s+(\S+))?$`),
+			Usage:       "grant access SLACKID role project-name|* [environment|*]",
+			Example:     "grant access U023A0BJUB1 releaser logistics-backend production",
+			Description: "give a user a role on one project, in one environment or all of them when it is omitted, it override a user group on the same scope",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				slackId, role, project, environment := parseSlackId(req.Args[1]), strings.ToLower(req.Args[2]), strings.ToLower(req.Args[3]), strings.ToLower(req.Args[4])
+				if models.RoleRank(role) == 0 {
+					return unknownRole(req, role)
+				}
+				if environment == "" {
+					environment = models.AnyScope
+				}
+				if !models.SetGrant(slackId, role, project, environment, req.User.Name) {
+					return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, I could not save the grant, please try again", req.User.ID), Color: "#e20228"}
+				}
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, %s is now %s on project %s in %s.", req.User.ID, slackId, role, project, environment)}
+			},
+		},
+		{
+			Name:        "revoke access",
+			Args:        regexp.MustCompile(`^(\S+)\s+(\S+)(?:\s+(\S+))?$`),
+			Usage:       "revoke access SLACKID project-name|* [environment|*]",
+			Example:     "revoke access U023A0BJUB1 logistics-backend production",
+			Description: "remove a grant given with grant access, a user group bound on the same scope apply again on the next sync",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				slackId, project, environment := parseSlackId(req.Args[1]), strings.ToLower(req.Args[2]), strings.ToLower(req.Args[3])
+				if environment == "" {
+					environment = models.AnyScope
+				}
+				if !models.DeleteGrant(slackId, project, environment) {
+					return slack.Attachment{Text: fmt.Sprintf("Hmm <@%s>, %s has no grant given by hand on project %s in %s, a grant from a user group is revoked by leaving the group", req.User.ID, slackId, project, environment)}
+				}
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, Removing the grant of %s on project %s in %s.", req.User.ID, slackId, project, environment)}
+			},
+		},
+		{
+			Name:        "grant list",
+			Args:        regexp.MustCompile(`^(\S+)?$`),
+			Usage:       "grant list [SLACKID]",
+			Description: "list the project grants, of one user when a slack id is given",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				slackId := ""
+				if req.Args[1] != "" {
+					slackId = parseSlackId(req.Args[1])
+				}
+				result := models.GetAllGrants(slackId)
+				if result == "" {
+					return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, there is no grant yet.", req.User.ID)}
+				}
+				return slack.Attachment{Text: fmt.Sprintf("Gotcha <@%s>, this is the grant list: \n\n %s", req.User.ID, result)}
+			},
+		},
+		{
+			Name:        "my role",
+			Description: "show your role and the projects you were granted",
+			Handler: func(req *CommandRequest) slack.Attachment {
+				role, grants := userRoles(req.User.ID)
+				text := fmt.Sprintf("Psst <@%s> your role is %s", req.User.ID, role)
+				for _, grant := range grants {
+					text += fmt.Sprintf("\n\t %s on project %s in %s", grant.Role, grant.Project, grant.Environment)
+				}
+				return slack.Attachment{Text: text, Color: "#563a9b", Footer: fmt.Sprintf("Current env is set to %s", currentEnvironment())}
+			},
+		},
+		{
+			Name:        "test access",
+			Description: "check that you have access",
+			Permission:  PermissionRelease,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				return slack.Attachment{Text: fmt.Sprintf("Congrats <@%s>, you have the access", req.User.ID)}
+			},
+		},
+	}
+}
+
+// handleDisableAccess disable an access list user, a user only known from a user group is added disabled
+// so the group sync cannot give them a role back until an admin enable or delete them
+func handleDisableAccess(req *CommandRequest) slack.Attachment {
+	slackId := parseSlackId(req.Args[1])
+	if models.ToogleUserStatus(slackId, false) {
+		return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, Disabling access for %s.", req.User.ID, slackId)}
+	}
+
+	name := slackId
+	if user, err := req.Client.GetUserInfo(slackId); err == nil && user.RealName != "" {
+		name = user.RealName
+	}
+	if !models.AddDisabledUser(slackId, name) {
+		return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, I could not disable %s, please try again", req.User.ID, slackId), Color: "#e20228"}
+	}
+	return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, %s was not in the access list, they are added disabled so no user group give them a role until enable access or delete access.", req.User.ID, slackId)}
+}
+
+func unknownRole(req *CommandRequest, role string) slack.Attachment {
+	return slack.Attachment{
+		Text:   fmt.Sprintf("Sorry <@%s>, %s is not a role, use one of %s", req.User.ID, role, strings.Join(models.Roles, ", ")),
+		Color:  "#e20228",
+		Footer: fmt.Sprintf("GRIP Release Bot cannot continue, '%s'", req.Text),
+	}
+}
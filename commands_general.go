@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/config"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+func generalCommands() []*Command {
+	return []*Command{
+		{
+			Name:        "my id",
+			Description: "show your slack id",
+			Handler: func(req *CommandRequest) slack.Attachment {
+				return slack.Attachment{
+					Text:  fmt.Sprintf("Psst <@%s> your slack id is %s", req.User.ID, req.User.ID),
+					Color: "#563a9b",
+				}
+			},
+		},
+		{
+			Name:        "help",
+			Description: "show the commands you can use, or the detail of one command",
+			Usage:       "help [command]",
+			Example:     "help schedule release",
+			Handler:     handleHelp,
+		},
+		{
+			Name:        "my timezone",
+			Description: "show the timezone I use to read and show time for you",
+			Handler: func(req *CommandRequest) slack.Attachment {
+				return slack.Attachment{
+					Text:  fmt.Sprintf("Psst <@%s> your timezone is %s, now is %s", req.User.ID, UserLocation(req.User).String(), time.Now().In(UserLocation(req.User)).Format(models.TimeLayout)),
+					Color: "#563a9b",
+				}
+			},
+		},
+		{
+			Name:        "set timezone",
+			Args:        regexp.MustCompile(`^(\S+)$`),
+			Usage:       "set timezone Area/City",
+			Example:     "set timezone Asia/Jakarta",
+			Description: "set the timezone used for your schedule and listing, 'default' to follow your slack profile",
+			Handler:     handleSetTimezone,
+		},
+		{
+			Name:        "who are you",
+			Description: "introduce the bot",
+			Handler: func(req *CommandRequest) slack.Attachment {
+				return slack.Attachment{
+					Text:   fmt.Sprintf("Yo <@%s>, I'm ~Snow King~ I mean Bot that handle release or deploy a project to server and living in GRIP Principle Slack 😁😁", req.User.ID),
+					Footer: "Build using Go.",
+					Color:  "#563a9b",
+				}
+			},
+		},
+		{
+			Name:        "env",
+			Description: "show the environment the bot is running in",
+			Handler: func(req *CommandRequest) slack.Attachment {
+				return slack.Attachment{
+					Text:   fmt.Sprintf("Bibop <@%s>, current env is set to %s", req.User.ID, config.GetConfig("ENVIRONMENT")),
+					Footer: "Build using Go.",
+					Color:  "#563a9b",
+				}
+			},
+		},
+	}
+}
+
+// handleHelp build the help from the registry, only listing what the caller is allowed to run
+func handleHelp(req *CommandRequest) slack.Attachment {
+	commands := botCommands.Allowed(req.User.ID)
+
+	if topic := req.Args[0]; topic != "" {
+		command, _ := NewCommandRegistry(commands...).Match(topic)
+		if command == nil {
+			return slack.Attachment{
+				Text:   fmt.Sprintf("Sorry <@%s>, there is no command '%s' you can use, try 'help' to see the list", req.User.ID, topic),
+				Color:  "#e20228",
+				Footer: "GRIP Release Bot.",
+			}
+		}
+		return slack.Attachment{
+			Text:   fmt.Sprintf("Easy <@%s>, %s, mention me with this message format '%s'", req.User.ID, command.Description, command.UsageText()),
+			Footer: helpExample(command),
+			Color:  "#563a9b",
+		}
+	}
+
+	var list strings.Builder
+	for i, command := range commands {
+		fmt.Fprintf(&list, "%d. *%s* - %s \n\t `%s`", i+1, command.Name, command.Description, command.UsageText())
+		if command.Example != "" {
+			fmt.Fprintf(&list, "\n\t e.g. `%s`", command.Example)
+		}
+		list.WriteString("\n")
+	}
+
+	return slack.Attachment{
+		Text:   fmt.Sprintf("Howdy <@%s> :mixue:, this is the availble command list\n%s", req.User.ID, list.String()),
+		Footer: "GRIP Release Bot.",
+		Color:  "#563a9b",
+	}
+}
+
+func helpExample(command *Command) string {
+	if command.Example == "" {
+		return "GRIP Release Bot."
+	}
+	return fmt.Sprintf("Example: %s", command.Example)
+}
+
+func handleSetTimezone(req *CommandRequest) slack.Attachment {
+	timezone := req.Args[1]
+	if strings.EqualFold(timezone, "default") {
+		models.SetUserTimezone(req.User.ID, "")
+		return slack.Attachment{
+			Text:  fmt.Sprintf("Noted <@%s>, I will use your slack profile timezone %s", req.User.ID, UserLocation(req.User).String()),
+			Color: "#4af030",
+		}
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return slack.Attachment{
+			Text:   fmt.Sprintf("Sorry <@%s>, %s is not a timezone I know, use the IANA name like Asia/Jakarta", req.User.ID, timezone),
+			Color:  "#e20228",
+			Footer: fmt.Sprintf("GRIP Release Bot cannot continue, '%s'", req.Text),
+		}
+	}
+
+	models.SetUserTimezone(req.User.ID, location.String())
+	return slack.Attachment{
+		Text:  fmt.Sprintf("Noted <@%s>, your timezone is now %s", req.User.ID, location.String()),
+		Color: "#4af030",
+	}
+}
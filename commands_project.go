@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+func projectCommands() []*Command {
+	return []*Command{
+		{
+			Name:        "project list",
+			Description: "list the projects and how they are released",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				result := models.GetAllProjects()
+				if result == "" {
+					return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, project list is empty.", req.User.ID)}
+				}
+				return slack.Attachment{Text: fmt.Sprintf("Gotcha <@%s>, this is the project list: \n\n %s", req.User.ID, result)}
+			},
+		},
+		{
+			Name:        "add project",
+			Args:        regexp.MustCompile(`^([^|]+)\|([^|]+)\|([^|]+)$`),
+			Usage:       "add project project name|jenkins token|jenkins host",
+			Example:     "add project logistics-backend|LOGISTICSBE|jenkins.example.com:8080",
+			Description: "register a project that can be released",
+			Permission:  PermissionAdmin,
+			Secret:      []int{2},
+			Handler: func(req *CommandRequest) slack.Attachment {
+				if !models.AddNewProject(req.Args[1], req.Args[2], req.Args[3]) {
+					return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, I could not add project %s, please try again", req.User.ID, req.Args[1]), Color: "#e20228"}
+				}
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, Adding project %s.", req.User.ID, req.Args[1])}
+			},
+		},
+		{
+			Name:        "delete project",
+			Args:        regexp.MustCompile(`^(\S+)$`),
+			Usage:       "delete project project-name",
+			Description: "remove a project",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				if !models.DeleteProject(req.Args[1]) {
+					return projectNotFound(req, req.Args[1])
+				}
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, Removing project %s.", req.User.ID, strings.ToUpper(req.Args[1]))}
+			},
+		},
+		{
+			Name:        "enable project",
+			Args:        regexp.MustCompile(`^(\S+)$`),
+			Usage:       "enable project project-name",
+			Description: "enable a disabled project",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				if !models.ToogleProject(req.Args[1], true) {
+					return projectNotFound(req, req.Args[1])
+				}
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, Enabling project %s.", req.User.ID, strings.ToUpper(req.Args[1]))}
+			},
+		},
+		{
+			Name:        "disable project",
+			Args:        regexp.MustCompile(`^(\S+)$`),
+			Usage:       "disable project project-name",
+			Description: "disable a project without removing it",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				if !models.ToogleProject(req.Args[1], false) {
+					return projectNotFound(req, req.Args[1])
+				}
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, Disabling project %s.", req.User.ID, strings.ToUpper(req.Args[1]))}
+			},
+		},
+		{
+			Name:        "set project timezone",
+			Args:        regexp.MustCompile(`^(\S+)\s+(\S+)$`),
+			Usage:       "set project timezone project-name Area/City",
+			Example:     "set project timezone logistics-backend Asia/Jakarta",
+			Description: "set the project default timezone, 'default' to use the bot timezone",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				timezone := req.Args[2]
+				if strings.EqualFold(timezone, "default") {
+					timezone = ""
+				} else if location, err := time.LoadLocation(timezone); err != nil {
+					return slack.Attachment{
+						Text:  fmt.Sprintf("Sorry <@%s>, %s is not a timezone I know, use the IANA name like Asia/Jakarta", req.User.ID, timezone),
+						Color: "#e20228",
+					}
+				} else {
+					timezone = location.String()
+				}
+
+				models.SetProjectTimezone(req.Args[1], timezone)
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, Project %s timezone is now %s.", req.User.ID, strings.ToUpper(req.Args[1]), ProjectLocation(req.Args[1]).String())}
+			},
+		},
+		{
+			Name:        "set project deployer",
+			Args:        regexp.MustCompile(`(?s)^(\S+)\s+(\S+)\s*(.*)$`),
+			Usage:       "set project deployer project-name jenkins|gitlab|github|webhook {json config}",
+			Example:     `set project deployer logistics-web github {"owner":"grip","repo":"logistics-web","workflow":"release.yml","ref":"main","token_env":"DEPLOY_LOGISTICS_WEB_GITHUB_TOKEN"}`,
+			Description: "choose how a project is released, jenkins use the token and host from add project, the other tokens are named by their bot environment variable, starting with DEPLOY_LOGISTICS_WEB_ for logistics-web",
+			Permission:  PermissionAdmin,
+			Secret:      []int{3},
+			Handler: func(req *CommandRequest) slack.Attachment {
+				kind, deployerConfig := strings.ToLower(req.Args[2]), req.Args[3]
+				if err := ValidateDeployerConfig(kind, req.Args[1], deployerConfig); err != nil {
+					return slack.Attachment{
+						Text:   fmt.Sprintf("Sorry <@%s>, %s, supported deployer are %s", req.User.ID, err.Error(), strings.Join(DeployerKinds, ", ")),
+						Color:  "#e20228",
+						Footer: "GRIP Release Bot cannot continue",
+					}
+				}
+				if kind == DeployerJenkins {
+					deployerConfig = ""
+				}
+
+				models.SetProjectDeployer(req.Args[1], kind, deployerConfig)
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, Project %s is now released with %s.", req.User.ID, strings.ToUpper(req.Args[1]), kind)}
+			},
+		},
+		{
+			Name:        "set project channel",
+			Args:        regexp.MustCompile(`^(\S+)\s+(\S+)$`),
+			Usage:       "set project channel project-name #channel",
+			Example:     "set project channel logistics #logistics-dev",
+			Description: "set the channel the project notices like sandbox builds are posted to, 'none' to clear it",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				channel := parseChannelId(req.Args[2])
+				if strings.EqualFold(req.Args[2], "none") {
+					channel = ""
+				} else if channel == "" {
+					return slack.Attachment{
+						Text:  fmt.Sprintf("Sorry <@%s>, mention the channel like #logistics-dev", req.User.ID),
+						Color: "#e20228",
+					}
+				}
+
+				models.SetProjectChannel(req.Args[1], channel)
+				if channel == "" {
+					return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, Project %s has no channel anymore.", req.User.ID, strings.ToUpper(req.Args[1]))}
+				}
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, Project %s notices now go to <#%s>.", req.User.ID, strings.ToUpper(req.Args[1]), channel)}
+			},
+		},
+		{
+			Name:        "test project",
+			Args:        regexp.MustCompile(`^(\S+)$`),
+			Usage:       "test project project-name",
+			Description: "show the jenkins token of a project",
+			Permission:  PermissionAdmin,
+			Private:     true,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				result := models.GetProjectToken(req.Args[1])
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, test project %s, the token is %s", req.User.ID, strings.ToUpper(req.Args[1]), result)}
+			},
+		},
+	}
+}
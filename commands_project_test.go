@@ -0,0 +1,35 @@
+package main
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestProjectCommandsReportMissingProject(t *testing.T) {
+	tests := []struct {
+		text     string
+		affected int64
+		reply    string
+	}{
+		{"delete project grip", 1, "Removing project GRIP"},
+		{"delete project nope", 0, "the project nope is not found"},
+		{"enable project grip", 1, "Enabling project GRIP"},
+		{"disable project nope", 0, "the project nope is not found"},
+		{"add project grip|TOKEN|jenkins.example.com", 1, "Adding project grip"},
+	}
+
+	for _, test := range tests {
+		useFakeDB(t, func(query string, args []driver.Value) fakeResult {
+			return fakeResult{affected: test.affected}
+		})
+
+		command, args := botCommands.Match(test.text)
+		attachment := command.Handler(&CommandRequest{User: &slack.User{ID: "U0TEST"}, Text: test.text, Args: command.Args.FindStringSubmatch(args)})
+		if !strings.Contains(attachment.Text, test.reply) {
+			t.Errorf("%s = %q, want it to contain %q", test.text, attachment.Text, test.reply)
+		}
+	}
+}
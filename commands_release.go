@@ -0,0 +1,296 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+func releaseCommands() []*Command {
+	return []*Command{
+		{
+			Name:        "active schedule",
+			Description: "list the release schedules that have not run yet",
+			Handler: func(req *CommandRequest) slack.Attachment {
+				attachment := slack.Attachment{Footer: "Build using Go.", Color: "#563a9b"}
+
+				result := models.GetActiveRelease(UserLocation(req.User))
+				recurrence := models.GetActiveRecurrence(UserLocation(req.User))
+				if result == "" && recurrence == "" {
+					attachment.Text = fmt.Sprintf("Woah <@%s>, currently no active schedule", req.User.ID)
+					return attachment
+				}
+
+				attachment.Text = fmt.Sprintf("Wow <@%s>, this is the active schedule: \n\n %s", req.User.ID, result)
+				if recurrence != "" {
+					attachment.Text += fmt.Sprintf("\n*Recurring schedule:* \n\n %s", recurrence)
+				}
+				return attachment
+			},
+		},
+		{
+			Name:        "remove schedule",
+			Args:        regexp.MustCompile(`^(\S+)$`),
+			Usage:       "remove schedule id",
+			Example:     "remove schedule 7ede5801-f6bb-4eaf-926f-54ee7f65905c",
+			Description: "cancel an active release schedule",
+			Permission:  PermissionRelease,
+			Handler:     handleRemoveSchedule,
+		},
+		{
+			Name:        "schedule release",
+			Args:        regexp.MustCompile(`^(\S+)\s*<<(.+)>>\s+(?:at\s+)?(.+)$`),
+			Usage:       "schedule release projectname <<version>> at when",
+			Example:     "schedule release logistics-backend <<backend-1.1.0-beta>> at tomorrow 9pm",
+			Description: "schedule a release, when is in your timezone and can be '2026-10-20 21:00', 'in 2h', 'tomorrow 9pm', 'friday 18:00' or '09:25PM'",
+			Permission:  PermissionRelease,
+			Handler:     handleScheduleRelease,
+		},
+		{
+			Name:        "release history",
+			Args:        regexp.MustCompile(`^(\S+)?(?:\s+(\d+))?$`),
+			Usage:       "release history [projectname] [count]",
+			Example:     "release history logistics-backend 5",
+			Description: "show what was released, cancelled or expired and who did it",
+			Handler:     handleReleaseHistory,
+		},
+		{
+			Name:        "every",
+			Args:        regexp.MustCompile(`^(.+?)\s+release\s+(\S+)\s*<<(.+)>>$`),
+			Usage:       "every when release projectname <<version>>",
+			Example:     "every weekday at 18:00 release logistics-web <<staging-latest>>",
+			Description: "create a recurring release, when is 'day|weekday|weekend|monday..sunday at hh:mm' or a cron expression like '0 18 * * 1-5' in your timezone",
+			Permission:  PermissionRelease,
+			Handler:     handleRecurringRelease,
+		},
+		{
+			Name:        "pause recurrence",
+			Args:        regexp.MustCompile(`^(\S+)$`),
+			Usage:       "pause recurrence id",
+			Description: "stop a recurring release from running until it is resumed",
+			Permission:  PermissionRelease,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				return handleRecurrenceStatus(req, models.RecurrencePaused)
+			},
+		},
+		{
+			Name:        "resume recurrence",
+			Args:        regexp.MustCompile(`^(\S+)$`),
+			Usage:       "resume recurrence id",
+			Description: "resume a paused recurring release",
+			Permission:  PermissionRelease,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				return handleRecurrenceStatus(req, models.RecurrenceActive)
+			},
+		},
+		{
+			Name:        "delete recurrence",
+			Args:        regexp.MustCompile(`^(\S+)$`),
+			Usage:       "delete recurrence id",
+			Description: "delete a recurring release, the releases it already made are kept",
+			Permission:  PermissionRelease,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				return handleRecurrenceStatus(req, models.RecurrenceDeleted)
+			},
+		},
+		{
+			Name:        "release",
+			Args:        regexp.MustCompile(`^(\S+)\s*<<(.+)>>`),
+			Usage:       "release projectname <<version>>",
+			Example:     "release logistics-backend <<backend-1.1.0-beta>>",
+			Description: "release a project version now, after you confirm the summary with the Confirm button",
+			Permission:  PermissionRelease,
+			Handler:     handleRelease,
+		},
+	}
+}
+
+func handleRemoveSchedule(req *CommandRequest) slack.Attachment {
+	attachment := slack.Attachment{Footer: "Build using Go.", Color: "#4af030"}
+
+	if releaseSchedule, ok := models.GetSchedule(req.Args[1]); ok && !PermissionRelease.AllowsProject(req.User.ID, releaseSchedule.ReleaseProject) {
+		return permissionDenied(req, releaseSchedule.ReleaseProject)
+	}
+	if !models.TransitionRelease(req.Args[1], models.ReleasePending, models.ReleaseCancelled, req.User.Name, "") {
+		attachment.Text = fmt.Sprintf("Hmm <@%s>, no active schedule with this Id", req.User.ID)
+	} else {
+		attachment.Text = fmt.Sprintf("Noted <@%s>, this schedule is removed :noted:", req.User.ID)
+	}
+	return attachment
+}
+
+func handleScheduleRelease(req *CommandRequest) slack.Attachment {
+	project, version := req.Args[1], req.Args[2]
+
+	releaseOn, location, err := validateSchedule(req.User, project, req.Args[3])
+	if errors.Is(err, errProjectNotAvailable) {
+		return projectNotFound(req, project)
+	}
+	if errors.Is(err, errReleaseNotAllowed) {
+		return permissionDenied(req, project)
+	}
+	if err != nil {
+		return slack.Attachment{
+			Text:   fmt.Sprintf("Sorry <@%s>, looks like your time is wrong (%s), use a date like 2026-10-20 21:00, a relative time like in 2h or tomorrow 9pm, or hh:mm in %s timezone", req.User.ID, err.Error(), location.String()),
+			Color:  "#e20228",
+			Footer: fmt.Sprintf("GRIP Release Bot cannot continue, '%s'", req.Text),
+		}
+	}
+
+	if _, err := models.CreateSchedule(project, version, releaseOn, req.User.Name, req.Channel, req.ThreadTs); err != nil {
+		return slack.Attachment{
+			Text:   fmt.Sprintf("Sorry <@%s>, I could not save the release schedule, please try again", req.User.ID),
+			Color:  "#e20228",
+			Footer: fmt.Sprintf("GRIP Release Bot cannot continue, %s", err.Error()),
+		}
+	}
+
+	return slack.Attachment{
+		Text:   fmt.Sprintf("Roger <@%s>, Create release schedule for %s version %s at %s", req.User.ID, project, version, models.FormatTime(int(releaseOn.Unix()), location)),
+		Color:  "#4af030",
+		Footer: "GRIP Release Bot create release schedule.",
+	}
+}
+
+// errProjectNotAvailable is returned by validateSchedule for an unknown or disabled project
+var errProjectNotAvailable = errors.New("project is not available")
+
+// errReleaseNotAllowed is returned by validateSchedule when the user cannot release the project
+var errReleaseNotAllowed = errors.New("not allowed to release the project")
+
+// validateSchedule check a schedule request the same way for the mention command and the modal,
+// when is parsed in the timezone the user schedule project in, which is returned with the release time
+func validateSchedule(user *slack.User, project string, when string) (time.Time, *time.Location, error) {
+	location := ScheduleLocation(user, project)
+	releaseOn, err := ParseReleaseTime(when, time.Now().In(location))
+	if err != nil {
+		return releaseOn, location, err
+	}
+
+	if !models.ProjectIsAvailable(project) {
+		return releaseOn, location, errProjectNotAvailable
+	}
+	if !PermissionRelease.AllowsProject(user.ID, project) {
+		return releaseOn, location, errReleaseNotAllowed
+	}
+	return releaseOn, location, nil
+}
+
+func handleRelease(req *CommandRequest) slack.Attachment {
+	project, version := req.Args[1], req.Args[2]
+
+	if !models.ProjectIsAvailable(project) {
+		return projectNotFound(req, project)
+	}
+	if !PermissionRelease.AllowsProject(req.User.ID, project) {
+		return permissionDenied(req, project)
+	}
+
+	log.Println(project, version)
+	// the release only start once the requester or an admin press Confirm, see release_confirm.go
+	return askReleaseConfirmation(req, project, version)
+}
+
+func handleReleaseHistory(req *CommandRequest) slack.Attachment {
+	project, count := req.Args[1], req.Args[2]
+	if count == "" {
+		if _, err := strconv.Atoi(project); err == nil {
+			// only the count was given
+			project, count = "", project
+		}
+	}
+
+	limit, err := strconv.Atoi(count)
+	if err != nil || limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	result := models.GetReleaseHistory(project, limit, UserLocation(req.User))
+	if result == "" {
+		return slack.Attachment{
+			Text:  fmt.Sprintf("Woah <@%s>, there is no release history yet", req.User.ID),
+			Color: "#563a9b",
+		}
+	}
+	return slack.Attachment{
+		Text:   fmt.Sprintf("Here you go <@%s>, the last %d release: \n\n %s", req.User.ID, limit, result),
+		Footer: "Build using Go.",
+		Color:  "#563a9b",
+	}
+}
+
+func handleRecurringRelease(req *CommandRequest) slack.Attachment {
+	project, version := req.Args[2], req.Args[3]
+
+	expr, schedule, err := ParseRecurrence(req.Args[1])
+	if err != nil {
+		return slack.Attachment{
+			Text:   fmt.Sprintf("Sorry <@%s>, %s, use 'weekday at 18:00' or a cron expression like '0 18 * * 1-5'", req.User.ID, err.Error()),
+			Color:  "#e20228",
+			Footer: fmt.Sprintf("GRIP Release Bot cannot continue, '%s'", req.Text),
+		}
+	}
+
+	if !models.ProjectIsAvailable(project) {
+		return projectNotFound(req, project)
+	}
+	if !PermissionRelease.AllowsProject(req.User.ID, project) {
+		return permissionDenied(req, project)
+	}
+
+	location := ScheduleLocation(req.User, project)
+	next := schedule.Next(time.Now().In(location))
+	id := models.CreateRecurrence(project, version, expr, location.String(), next, req.User.Name, req.Channel)
+
+	return slack.Attachment{
+		Text:   fmt.Sprintf("Roger <@%s>, Create recurring release for %s version %s every `%s` (%s), next run at %s \n\t Id: %s", req.User.ID, project, version, expr, location.String(), models.FormatTime(int(next.Unix()), UserLocation(req.User)), id),
+		Color:  "#4af030",
+		Footer: "GRIP Release Bot create recurring release.",
+	}
+}
+
+func handleRecurrenceStatus(req *CommandRequest, status string) slack.Attachment {
+	recurrence, ok := models.GetRecurrence(req.Args[1])
+	if !ok {
+		return slack.Attachment{
+			Text:  fmt.Sprintf("Hmm <@%s>, no recurring release with this Id", req.User.ID),
+			Color: "#e20228",
+		}
+	}
+	if !PermissionRelease.AllowsProject(req.User.ID, recurrence.ReleaseProject) {
+		return permissionDenied(req, recurrence.ReleaseProject)
+	}
+
+	next := time.Unix(int64(recurrence.NextRunOn), 0)
+	if status == models.RecurrenceActive {
+		var err error
+		next, err = NextRecurrenceRun(recurrence, time.Now())
+		if err != nil {
+			return slack.Attachment{
+				Text:  fmt.Sprintf("Sorry <@%s>, cannot resume this recurring release, %s", req.User.ID, err.Error()),
+				Color: "#e20228",
+			}
+		}
+	}
+	models.SetRecurrenceStatus(recurrence.Id, status, next)
+
+	text := fmt.Sprintf("Noted <@%s>, recurring release %s > %s is %s :noted:", req.User.ID, recurrence.ReleaseProject, recurrence.ReleaseVersion, status)
+	if status == models.RecurrenceActive {
+		text += fmt.Sprintf(", next run at %s", models.FormatTime(int(next.Unix()), UserLocation(req.User)))
+	}
+	return slack.Attachment{Text: text, Color: "#4af030"}
+}
+
+func projectNotFound(req *CommandRequest, project string) slack.Attachment {
+	return slack.Attachment{
+		Text:   fmt.Sprintf("Sorry <@%s>, the project %s is not found, use command project list to see the supported project", req.User.ID, project),
+		Color:  "#e20228",
+		Footer: fmt.Sprintf("GRIP Release Bot cannot continue, '%s'", req.Text),
+	}
+}
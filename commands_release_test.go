@@ -0,0 +1,44 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestScheduleReleaseReportSaveError(t *testing.T) {
+	tests := []struct {
+		name    string
+		saveErr error
+		reply   string
+	}{
+		{"saved", nil, "Create release schedule for grip version 1.2.0"},
+		{"insert failed", errors.New("connection refused"), "I could not save the release schedule"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("DEFAULT_ROLE", "releaser")
+			useFakeDB(t, func(query string, args []driver.Value) fakeResult {
+				switch {
+				case strings.HasPrefix(query, "Select id from projects"):
+					return fakeResult{rows: [][]driver.Value{{"p1"}}}
+				case strings.HasPrefix(query, "INSERT INTO release_schedule"):
+					return fakeResult{affected: 1, err: test.saveErr}
+				}
+				return fakeResult{}
+			})
+
+			attachment := handleScheduleRelease(&CommandRequest{
+				User: &slack.User{ID: "U0TEST", Name: "tester"},
+				Args: []string{"grip <<1.2.0>> in 2h", "grip", "1.2.0", "in 2h"},
+			})
+			if !strings.Contains(attachment.Text, test.reply) {
+				t.Errorf("reply = %q, want it to contain %q", attachment.Text, test.reply)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// sandboxClaimDefault is how long a claim last when no duration is given
+const sandboxClaimDefault = 4 * time.Hour
+
+// sandboxClaimMax is the longest duration a server can be claimed for at once
+const sandboxClaimMax = 72 * time.Hour
+
+func sandboxCommands() []*Command {
+	return []*Command{
+		{
+			Name:        "sandbox server status",
+			Args:        regexp.MustCompile(`^(\S+)$`),
+			Usage:       "sandbox server status project-name",
+			Example:     "sandbox server status logistics",
+			Description: "show the sandbox servers of a project",
+			Handler:     handleSandboxServerStatus,
+		},
+		{
+			Name:        "claim sandbox server",
+			Args:        regexp.MustCompile(`(?i)^([^,\s]+)\s*,\s*(\S+)(?:\s+for\s+(\d+\s*[a-z]+))?(?:\s+(.+))?$`),
+			Usage:       "claim sandbox server project-name,server-id [for duration] [reason]",
+			Example:     "claim sandbox server logistics,1 for 2h testing the new checkout",
+			Description: fmt.Sprintf("mark a sandbox server as in use by you, the duration default to %s", sandboxClaimDefault),
+			Permission:  PermissionSandbox,
+			Handler:     handleClaimSandboxServer,
+		},
+		{
+			Name:        "release sandbox server",
+			Args:        regexp.MustCompile(`^([^,\s]+)\s*,\s*(\S+)$`),
+			Usage:       "release sandbox server project-name,server-id",
+			Example:     "release sandbox server logistics,1",
+			Description: "give back a sandbox server you claimed",
+			Handler:     handleReleaseSandboxServer,
+		},
+		{
+			Name:        "done sandbox server",
+			Args:        regexp.MustCompile(`^([^,\s]+)\s*,\s*(\S+)$`),
+			Usage:       "done sandbox server project-name,server-id",
+			Example:     "done sandbox server logistics,1",
+			Description: "mark a sandbox server as not in use",
+			Handler:     handleReleaseSandboxServer,
+		},
+		{
+			Name:        "sandbox deploy",
+			Args:        regexp.MustCompile(`(?i)^([^,\s]+)\s*[,\s]\s*(\S+)((?:\s+(?:fe|be)=\S+)+)$`),
+			Usage:       "sandbox deploy project-name server-id fe=branch be=branch",
+			Example:     "sandbox deploy logistics 1 fe=feature/checkout be=develop",
+			Description: "deploy branches to a sandbox server and claim it for you, a missing branch keep the last one",
+			Permission:  PermissionSandbox,
+			Handler:     handleSandboxDeploy,
+		},
+		{
+			Name:        "sandbox report",
+			Args:        regexp.MustCompile(`(?i)^([^,\s]+)(?:\s*[,\s]\s*(week|month))?$`),
+			Usage:       "sandbox report project-name [week|month]",
+			Example:     "sandbox report logistics month",
+			Description: "show how long each sandbox server was in use, by whom, and the waitlist, over the last week by default",
+			Handler:     handleSandboxReport,
+		},
+		{
+			Name:        "sandbox wait",
+			Args:        regexp.MustCompile(`^(\S+)$`),
+			Usage:       "sandbox wait project-name",
+			Example:     "sandbox wait logistics",
+			Description: "join the waitlist of a project, I will DM you when a sandbox server is free",
+			Permission:  PermissionSandbox,
+			Handler:     handleSandboxWait,
+		},
+		{
+			Name:        "sandbox leave",
+			Args:        regexp.MustCompile(`^(\S+)$`),
+			Usage:       "sandbox leave project-name",
+			Example:     "sandbox leave logistics",
+			Description: "leave the waitlist of a project",
+			Handler:     handleSandboxLeave,
+		},
+	}
+}
+
+func handleSandboxServerStatus(req *CommandRequest) slack.Attachment {
+	result := models.GetServerStatus(req.Args[1], UserLocation(req.User))
+	if result == "" {
+		return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, Sandbox Server Status not found.", req.User.ID), Color: "#e20228"}
+	}
+	if waitlist := models.GetWaitlist(req.Args[1]); len(waitlist) > 0 {
+		var names []string
+		for _, entry := range waitlist {
+			names = append(names, entry.UserName)
+		}
+		result += fmt.Sprintf("Waitlist: %s \n", strings.Join(names, ", "))
+	}
+	return slack.Attachment{Text: fmt.Sprintf("Gotcha <@%s>, this is the Sandbox Server Status for project %s: \n\n %s", req.User.ID, req.Args[1], result)}
+}
+
+func handleClaimSandboxServer(req *CommandRequest) slack.Attachment {
+	project, serverId, duration, reason := strings.ToLower(req.Args[1]), req.Args[2], req.Args[3], req.Args[4]
+	location := UserLocation(req.User)
+	if !PermissionSandbox.AllowsProject(req.User.ID, project) {
+		return permissionDenied(req, project)
+	}
+
+	until := time.Now().Add(sandboxClaimDefault)
+	if duration != "" {
+		var err error
+		until, err = ParseReleaseTime("in "+duration, time.Now())
+		if err != nil || time.Until(until) > sandboxClaimMax {
+			return slack.Attachment{
+				Text:   fmt.Sprintf("Sorry <@%s>, the duration '%s' is not valid, use something like 30m, 2h or 1d up to %s", req.User.ID, duration, sandboxClaimMax),
+				Color:  "#e20228",
+				Footer: fmt.Sprintf("GRIP Release Bot cannot continue, '%s'", req.Text),
+			}
+		}
+	}
+
+	server, ok := models.GetServer(project, serverId)
+	if !ok {
+		return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, there is no sandbox server %s for project %s.", req.User.ID, serverId, project), Color: "#e20228"}
+	}
+
+	if !models.ClaimServer(project, serverId, req.User.ID, sandboxUserName(req.User), reason, until) {
+		// reload, the server may have been claimed between the two queries
+		if current, ok := models.GetServer(project, serverId); ok {
+			server = current
+		}
+		return sandboxServerInUse(req, server, location)
+	}
+
+	return slack.Attachment{
+		Text:   fmt.Sprintf("On it <@%s>, sandbox server %s of project %s is yours until %s", req.User.ID, serverId, project, models.FormatTime(int(until.Unix()), location)),
+		Color:  "#4af030",
+		Footer: "Use release sandbox server once you are done.",
+	}
+}
+
+// handleReleaseSandboxServer is open to everyone like before the roles, a viewer can still hand back
+// a server marked in use outside the bot
+func handleReleaseSandboxServer(req *CommandRequest) slack.Attachment {
+	project, serverId := strings.ToLower(req.Args[1]), req.Args[2]
+
+	server, ok := models.GetServer(project, serverId)
+	if !ok {
+		return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, there is no sandbox server %s for project %s.", req.User.ID, serverId, project), Color: "#e20228"}
+	}
+
+	// a claim made from the bot can only be given back by its holder or an admin
+	if server.Status && server.ClaimedById != "" && server.ClaimedById != req.User.ID && !PermissionAdmin.AllowsProject(req.User.ID, project) {
+		return sandboxServerInUse(req, server, UserLocation(req.User))
+	}
+
+	releaseSandboxServer(req.Client, project, serverId, sandboxUserName(req.User))
+
+	return slack.Attachment{Text: fmt.Sprintf("On it <@%s>, Set Sandbox Server Status project %s and server %s to Not in use", req.User.ID, project, serverId)}
+}
+
+// sandboxServerInUse explain who hold the server the user wanted
+func sandboxServerInUse(req *CommandRequest, server models.TestingStatus, location *time.Location) slack.Attachment {
+	holder := server.StatusChangedBy
+	if server.ClaimedById != "" {
+		holder = fmt.Sprintf("<@%s>", server.ClaimedById)
+	}
+	text := fmt.Sprintf("Sorry <@%s>, sandbox server %s of project %s is in use by %s since %s", req.User.ID, server.ServerId, server.Project, holder, models.FormatTime(server.StatusChangedOn, location))
+	if server.ClaimUntil > 0 {
+		text += fmt.Sprintf(", expected until %s", models.FormatTime(server.ClaimUntil, location))
+	}
+	if server.ClaimReason != "" {
+		text += fmt.Sprintf(" (%s)", server.ClaimReason)
+	}
+	return slack.Attachment{Text: text, Color: "#e20228", Footer: fmt.Sprintf("Use sandbox wait %s to be told when a server is free.", server.Project)}
+}
+
+func handleSandboxWait(req *CommandRequest) slack.Attachment {
+	project := strings.ToLower(req.Args[1])
+	if !PermissionSandbox.AllowsProject(req.User.ID, project) {
+		return permissionDenied(req, project)
+	}
+
+	if serverId, ok := models.GetFreeServer(project); ok {
+		return slack.Attachment{Text: fmt.Sprintf("Good news <@%s>, sandbox server %s of project %s is free, claim it with claim sandbox server %s,%s", req.User.ID, serverId, project, project, serverId)}
+	}
+	if !models.HasSandboxServer(project) {
+		return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, project %s has no sandbox server.", req.User.ID, project), Color: "#e20228"}
+	}
+
+	position, created := models.JoinWaitlist(project, req.User.ID, sandboxUserName(req.User))
+	if position == 0 {
+		return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, I could not add you to the waitlist, please try again", req.User.ID), Color: "#e20228"}
+	}
+	if !created {
+		return slack.Attachment{Text: fmt.Sprintf("Hmm <@%s>, you are already number %d in the waitlist of %s", req.User.ID, position, project)}
+	}
+	return slack.Attachment{
+		Text:   fmt.Sprintf("Noted <@%s>, you are number %d in the waitlist of %s, I will DM you when a sandbox server is free", req.User.ID, position, project),
+		Color:  "#4af030",
+		Footer: fmt.Sprintf("You will have %s to take the server.", sandboxOfferWindow),
+	}
+}
+
+func handleSandboxLeave(req *CommandRequest) slack.Attachment {
+	project := strings.ToLower(req.Args[1])
+	if !models.LeaveWaitlist(project, req.User.ID) {
+		return slack.Attachment{Text: fmt.Sprintf("Hmm <@%s>, you are not in the waitlist of %s", req.User.ID, project)}
+	}
+	return slack.Attachment{Text: fmt.Sprintf("Noted <@%s>, you left the waitlist of %s", req.User.ID, project), Color: "#4af030"}
+}
+
+// sandboxUserName is the name recorded on testing_status, the access list name or the slack name for the others
+func sandboxUserName(user *slack.User) string {
+	if name := models.GetUserName(user.ID); name != "" {
+		return name
+	}
+	if user.RealName != "" {
+		return user.RealName
+	}
+	return user.Name
+}
+
+// completeSandboxProject resolve a project typed in full or by a unique prefix,
+// the candidates are returned when it is empty, unknown or ambiguous
+func completeSandboxProject(input string) (string, []string) {
+	input = strings.ToLower(input)
+	projects := models.GetSandboxProjects()
+
+	var candidates []string
+	for _, project := range projects {
+		if project == input {
+			return project, nil
+		}
+		if strings.HasPrefix(project, input) {
+			candidates = append(candidates, project)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	if len(candidates) == 0 {
+		return "", projects
+	}
+	return "", candidates
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// sandboxInfoArg match the url=, description=, team= and health= metadata, a value with spaces is quoted
+var sandboxInfoArg = regexp.MustCompile(`(?i)\b(url|description|team|health)=("[^"]*"|\S+)`)
+
+func sandboxAdminCommands() []*Command {
+	return []*Command{
+		{
+			Name:        "add sandbox server",
+			Args:        regexp.MustCompile(`^([^,\s]+)\s*,\s*(\S+)(.*)$`),
+			Usage:       `add sandbox server project-name,server-id [url=https://.. description="..." team=.. health=https://..]`,
+			Example:     `add sandbox server logistics,3 url=https://sandbox3.logistics.dev description="checkout team box" team=checkout`,
+			Description: "add a sandbox server to a project",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				info := parseSandboxInfo(req.Args[3], models.SandboxServerInfo{})
+				if !validHealthURL(info.HealthURL) {
+					return invalidHealthURL(req, info.HealthURL)
+				}
+				if !models.AddServer(req.Args[1], req.Args[2], info) {
+					return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, I could not add sandbox server %s to %s, maybe it already exists", req.User.ID, req.Args[2], req.Args[1]), Color: "#e20228"}
+				}
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, sandbox server %s is added to %s.", req.User.ID, req.Args[2], strings.ToLower(req.Args[1]))}
+			},
+		},
+		{
+			Name:        "delete sandbox server",
+			Args:        regexp.MustCompile(`^([^,\s]+)\s*,\s*(\S+)$`),
+			Usage:       "delete sandbox server project-name,server-id",
+			Example:     "delete sandbox server logistics,3",
+			Description: "remove a sandbox server from a project",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				if !models.DeleteServer(req.Args[1], req.Args[2]) {
+					return sandboxServerNotFound(req)
+				}
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, sandbox server %s of %s is deleted.", req.User.ID, req.Args[2], strings.ToLower(req.Args[1]))}
+			},
+		},
+		{
+			Name:        "rename sandbox server",
+			Args:        regexp.MustCompile(`^([^,\s]+)\s*,\s*(\S+)\s+(\S+)$`),
+			Usage:       "rename sandbox server project-name,server-id new-server-id",
+			Example:     "rename sandbox server logistics,3 checkout-1",
+			Description: "change the id of a sandbox server",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				if _, exists := models.GetServer(strings.ToLower(req.Args[1]), req.Args[3]); exists {
+					return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, %s already has a sandbox server %s", req.User.ID, req.Args[1], req.Args[3]), Color: "#e20228"}
+				}
+				if !models.RenameServer(req.Args[1], req.Args[2], req.Args[3]) {
+					return sandboxServerNotFound(req)
+				}
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, sandbox server %s of %s is now %s.", req.User.ID, req.Args[2], strings.ToLower(req.Args[1]), req.Args[3])}
+			},
+		},
+		{
+			Name:        "enable sandbox server",
+			Args:        regexp.MustCompile(`^([^,\s]+)\s*,\s*(\S+)$`),
+			Usage:       "enable sandbox server project-name,server-id",
+			Description: "put a disabled sandbox server back in rotation",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				if !models.ToggleServer(req.Args[1], req.Args[2], true) {
+					return sandboxServerNotFound(req)
+				}
+				// someone may be waiting for this project
+				offerSandboxServer(req.Client, strings.ToLower(req.Args[1]), req.Args[2])
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, sandbox server %s of %s is enabled.", req.User.ID, req.Args[2], strings.ToLower(req.Args[1]))}
+			},
+		},
+		{
+			Name:        "disable sandbox server",
+			Args:        regexp.MustCompile(`^([^,\s]+)\s*,\s*(\S+)$`),
+			Usage:       "disable sandbox server project-name,server-id",
+			Description: "take a sandbox server out of rotation, it cannot be claimed until it is enabled",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				if !models.ToggleServer(req.Args[1], req.Args[2], false) {
+					return sandboxServerNotFound(req)
+				}
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, sandbox server %s of %s is disabled.", req.User.ID, req.Args[2], strings.ToLower(req.Args[1]))}
+			},
+		},
+		{
+			Name:        "set sandbox server",
+			Args:        regexp.MustCompile(`^([^,\s]+)\s*,\s*(\S+)(.+)$`),
+			Usage:       `set sandbox server project-name,server-id [url=..] [description=".."] [team=..] [health=..]`,
+			Example:     `set sandbox server logistics,3 health=https://sandbox3.logistics.dev/health`,
+			Description: "change the metadata of a sandbox server, health is the url I probe every minute, an empty value like team=\"\" clear it",
+			Permission:  PermissionAdmin,
+			Handler: func(req *CommandRequest) slack.Attachment {
+				server, ok := models.GetServer(strings.ToLower(req.Args[1]), req.Args[2])
+				if !ok {
+					return sandboxServerNotFound(req)
+				}
+				current := models.SandboxServerInfo{URL: server.URL, Description: server.Description, OwnerTeam: server.OwnerTeam, HealthURL: server.HealthURL}
+				info := parseSandboxInfo(req.Args[3], current)
+				if !validHealthURL(info.HealthURL) {
+					return invalidHealthURL(req, info.HealthURL)
+				}
+				models.SetServerInfo(req.Args[1], req.Args[2], info)
+				return slack.Attachment{Text: fmt.Sprintf("Roger <@%s>, sandbox server %s of %s is updated.", req.User.ID, req.Args[2], strings.ToLower(req.Args[1]))}
+			},
+		},
+	}
+}
+
+// parseSandboxInfo apply the url=, description=, team= and health= arguments on top of info
+func parseSandboxInfo(text string, info models.SandboxServerInfo) models.SandboxServerInfo {
+	for _, match := range sandboxInfoArg.FindAllStringSubmatch(text, -1) {
+		value := strings.Trim(match[2], `"`)
+		switch strings.ToLower(match[1]) {
+		case "url":
+			info.URL = value
+		case "description":
+			info.Description = value
+		case "team":
+			info.OwnerTeam = value
+		case "health":
+			info.HealthURL = value
+		}
+	}
+	return info
+}
+
+func sandboxServerNotFound(req *CommandRequest) slack.Attachment {
+	return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, there is no sandbox server %s for project %s.", req.User.ID, req.Args[2], strings.ToLower(req.Args[1])), Color: "#e20228"}
+}
+
+// validHealthURL accept an empty url, which disable the probe, or an http(s) url
+func validHealthURL(health string) bool {
+	if health == "" {
+		return true
+	}
+	target, err := url.Parse(health)
+	return err == nil && (target.Scheme == "http" || target.Scheme == "https") && target.Host != ""
+}
+
+func invalidHealthURL(req *CommandRequest, health string) slack.Attachment {
+	return slack.Attachment{
+		Text:   fmt.Sprintf("Sorry <@%s>, the health url '%s' is not valid, it must start with http:// or https://", req.User.ID, health),
+		Color:  "#e20228",
+		Footer: fmt.Sprintf("GRIP Release Bot cannot continue, '%s'", req.Text),
+	}
+}
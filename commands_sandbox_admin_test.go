@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+func TestSandboxAdminArgs(t *testing.T) {
+	tests := []struct {
+		command string
+		text    string
+		args    []string
+	}{
+		{"add sandbox server", "logistics,3", []string{"logistics", "3", ""}},
+		{"add sandbox server", `Logistics , 3 url=https://sb3.dev team=checkout`, []string{"Logistics", "3", " url=https://sb3.dev team=checkout"}},
+		{"add sandbox server", "logistics 3", nil},
+		{"delete sandbox server", "logistics,3", []string{"logistics", "3"}},
+		{"delete sandbox server", "logistics,3 now", nil},
+		{"rename sandbox server", "logistics,3 checkout-1", []string{"logistics", "3", "checkout-1"}},
+		{"rename sandbox server", "logistics,3", nil},
+		{"enable sandbox server", "logistics, 3", []string{"logistics", "3"}},
+		{"disable sandbox server", "logistics,3", []string{"logistics", "3"}},
+		{"set sandbox server", `logistics,3 description="checkout box"`, []string{"logistics", "3", ` description="checkout box"`}},
+		{"set sandbox server", "logistics,3", nil},
+	}
+
+	commands := map[string]*Command{}
+	for _, command := range sandboxAdminCommands() {
+		commands[command.Name] = command
+	}
+	for _, test := range tests {
+		match := commands[test.command].Args.FindStringSubmatch(test.text)
+		if test.args == nil {
+			if match != nil {
+				t.Errorf("%s %q matched %q, want no match", test.command, test.text, match[1:])
+			}
+			continue
+		}
+		if match == nil || strings.Join(match[1:], "|") != strings.Join(test.args, "|") {
+			t.Errorf("%s %q = %q, want %q", test.command, test.text, match, test.args)
+		}
+	}
+}
+
+func TestParseSandboxInfo(t *testing.T) {
+	current := models.SandboxServerInfo{URL: "https://sb3.dev", Description: "checkout box", OwnerTeam: "checkout", HealthURL: "https://sb3.dev/health"}
+	tests := []struct {
+		name string
+		text string
+		want models.SandboxServerInfo
+	}{
+		{"nothing to change", "", current},
+		{"every field", `url=https://sb4.dev description="payment box" team=payment health=http://sb4.dev/ping`,
+			models.SandboxServerInfo{URL: "https://sb4.dev", Description: "payment box", OwnerTeam: "payment", HealthURL: "http://sb4.dev/ping"}},
+		{"one field keep the others", " team=payment", models.SandboxServerInfo{URL: "https://sb3.dev", Description: "checkout box", OwnerTeam: "payment", HealthURL: "https://sb3.dev/health"}},
+		{"empty value clear the field", ` health="" TEAM=""`, models.SandboxServerInfo{URL: "https://sb3.dev", Description: "checkout box"}},
+		{"unquoted description stop at the space", " description=payment box", models.SandboxServerInfo{URL: "https://sb3.dev", Description: "payment", OwnerTeam: "checkout", HealthURL: "https://sb3.dev/health"}},
+		{"unknown key is ignored", " owner=payment", current},
+	}
+
+	for _, test := range tests {
+		if got := parseSandboxInfo(test.text, current); got != test.want {
+			t.Errorf("%s: parseSandboxInfo(%q) = %+v, want %+v", test.name, test.text, got, test.want)
+		}
+	}
+}
+
+func TestValidHealthURL(t *testing.T) {
+	tests := []struct {
+		health string
+		valid  bool
+	}{
+		{"", true},
+		{"https://sb3.dev/health", true},
+		{"http://10.0.0.3:8080/ping", true},
+		{"sb3.dev/health", false},
+		{"ftp://sb3.dev/health", false},
+		{"https://", false},
+		{"https://sb3.dev/%zz", false},
+	}
+
+	for _, test := range tests {
+		if got := validHealthURL(test.health); got != test.valid {
+			t.Errorf("validHealthURL(%q) = %v, want %v", test.health, got, test.valid)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestViewerReleaseSandboxServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		// claimedBy is the slack id of a claim made from the bot, empty for a server marked in use outside the bot
+		claimedBy string
+		free      bool
+		reply     string
+	}{
+		{"done a server in use", "done sandbox server grip,sb1", "", true, "to Not in use"},
+		{"release a server in use", "release sandbox server grip,sb1", "", true, "to Not in use"},
+		{"release own claim", "release sandbox server grip,sb1", "U0VIEWER", true, "to Not in use"},
+		{"release a claim of someone else", "done sandbox server grip,sb1", "U0ALICE", false, "is in use by <@U0ALICE>"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("DEFAULT_ROLE", "")
+			sandbox := newFakeSandbox("sb1")
+			server := sandbox.servers["sb1"]
+			server.Status, server.StatusChangedBy, server.ClaimedById = true, "someone", test.claimedBy
+			useFakeDB(t, sandbox.handle)
+			client, _ := newFakeSlackClient(t)
+
+			attachment, matched := NewCommandRegistry(sandboxCommands()...).Dispatch(&CommandRequest{
+				Client: client,
+				User:   &slack.User{ID: "U0VIEWER", Name: "viewer"},
+				Text:   test.command,
+			})
+			if !matched {
+				t.Fatalf("%q did not match a command", test.command)
+			}
+			if !strings.Contains(attachment.Text, test.reply) {
+				t.Errorf("reply = %q, want it to contain %q", attachment.Text, test.reply)
+			}
+			if server.Status == test.free {
+				t.Errorf("sb1 in use = %v, want free %v", server.Status, test.free)
+			}
+		})
+	}
+}
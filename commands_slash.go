@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// releaseSubcommands map the /release subcommands to the mention command they run
+var releaseSubcommands = map[string]string{
+	"now":      "release",
+	"schedule": "schedule release",
+	"list":     "active schedule",
+	"cancel":   "remove schedule",
+	"projects": "project list",
+	"access":   "access list",
+}
+
+// releaseSubcommandVerbs is the verbs accepted after projects and access, e.g. /release access add U123-Name
+// run "add access U123-Name", without a verb the list is shown
+var releaseSubcommandVerbs = map[string]string{
+	"projects": "project",
+	"access":   "access",
+}
+
+var releaseVerbs = []string{"add", "delete", "enable", "disable", "test"}
+
+// releaseSubcommandText turn the /release text into the mention command text, false when the subcommand is unknown
+func releaseSubcommandText(text string) (string, bool) {
+	subcommand, args, _ := strings.Cut(strings.TrimSpace(text), " ")
+	subcommand = strings.ToLower(subcommand)
+	args = strings.TrimSpace(args)
+
+	name, ok := releaseSubcommands[subcommand]
+	if !ok {
+		return "", false
+	}
+
+	if noun, ok := releaseSubcommandVerbs[subcommand]; ok && args != "" {
+		verb, rest, _ := strings.Cut(args, " ")
+		if strings.EqualFold(verb, "list") {
+			return name, true
+		}
+		for _, accepted := range releaseVerbs {
+			if strings.EqualFold(verb, accepted) {
+				return strings.TrimSpace(fmt.Sprintf("%s %s %s", accepted, noun, rest)), true
+			}
+		}
+		return "", false
+	}
+
+	return strings.TrimSpace(name + " " + args), true
+}
+
+// handleReleaseCommand run /release through the same command registry as the mentions,
+// errors and permission denials are only shown to the caller
+func handleReleaseCommand(command slack.SlashCommand, client *slack.Client) error {
+	user, err := client.GetUserInfo(command.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	text, ok := releaseSubcommandText(html.UnescapeString(normalizeText(command.Text)))
+	if !ok {
+		return postSlashReply(client, command, slack.Attachment{
+			Text:   fmt.Sprintf("Hi <@%s>, use `/release now|schedule|list|cancel|projects|access`, e.g.\n%s", user.ID, releaseCommandHelp()),
+			Color:  "#563a9b",
+			Footer: "GRIP Release Bot",
+		}, true)
+	}
+	log.Println(command.Command, text)
+
+	attachment, _ := botCommands.Dispatch(&CommandRequest{
+		Client:  client,
+		User:    user,
+		Channel: command.ChannelID,
+		Text:    text,
+		Post:    slashPost(client, command, false),
+	})
+
+	// the handler already replied
+	if attachment.Text == "" {
+		return nil
+	}
+	return postSlashReply(client, command, attachment, releaseReplyPrivate(text, attachment))
+}
+
+// releaseReplyPrivate tell if a /release reply is only shown to the caller, an error or the reply of a private command
+func releaseReplyPrivate(text string, attachment slack.Attachment) bool {
+	matched, _ := botCommands.Match(text)
+	return attachment.Color == "#e20228" || (matched != nil && matched.Private)
+}
+
+// releaseCommandHelp list the usage of every /release subcommand
+func releaseCommandHelp() string {
+	lines := []string{
+		"`/release now projectname <<version>>`",
+		"`/release schedule projectname <<version>> at hh:mm`",
+		"`/release list`",
+		"`/release cancel id`",
+		"`/release projects [add|delete|enable|disable|test ...]`",
+		"`/release access [add|delete|enable|disable|test ...]`",
+	}
+	return strings.Join(lines, "\n")
+}
+
+// postSlashReply answer a slash command in the channel, or only to the caller when ephemeral,
+// a channel the bot is not a member of fall back to a direct message
+func postSlashReply(client *slack.Client, command slack.SlashCommand, attachment slack.Attachment, ephemeral bool) error {
+	var err error
+	if ephemeral {
+		_, err = client.PostEphemeral(command.ChannelID, command.UserID, slack.MsgOptionAttachments(attachment))
+	} else {
+		_, _, err = client.PostMessage(command.ChannelID, slack.MsgOptionAttachments(attachment))
+	}
+	if err == nil {
+		return nil
+	}
+
+	log.Println("failed to reply in channel", command.ChannelID, err.Error())
+	if _, _, err := client.PostMessage(command.UserID, slack.MsgOptionAttachments(attachment)); err != nil {
+		return fmt.Errorf("failed to post message: %w", err)
+	}
+	return nil
+}
+
+// slashPost is the CommandRequest.Post of a slash command, an ephemeral post only go to the caller and
+// fall back to a direct message like postSlashReply, its timestamp is empty since it cannot be updated
+func slashPost(client *slack.Client, command slack.SlashCommand, ephemeral bool) func(options ...slack.MsgOption) (string, error) {
+	return func(options ...slack.MsgOption) (string, error) {
+		if !ephemeral {
+			_, ts, err := client.PostMessage(command.ChannelID, options...)
+			return ts, err
+		}
+
+		_, err := client.PostEphemeral(command.ChannelID, command.UserID, options...)
+		if err == nil {
+			return "", nil
+		}
+		log.Println("failed to reply in channel", command.ChannelID, err.Error())
+		_, ts, err := client.PostMessage(command.UserID, options...)
+		return ts, err
+	}
+}
+
+// block action id of the project picker shown when /sandbox status has no project
+const actionSandboxStatusProject = "sandbox_status_project"
+
+// sandboxPublicFlag post the /sandbox reply in the channel instead of only to the caller
+const sandboxPublicFlag = "--public"
+
+// sandboxSubcommands map the /sandbox subcommands to the mention command they run
+var sandboxSubcommands = map[string]string{
+	"status":  "sandbox server status",
+	"claim":   "claim sandbox server",
+	"release": "release sandbox server",
+	"done":    "done sandbox server",
+	"deploy":  "sandbox deploy",
+	"wait":    "sandbox wait",
+	"leave":   "sandbox leave",
+	"report":  "sandbox report",
+}
+
+// handleSandboxCommand run /sandbox status|claim|release|done|deploy|wait|leave|report <project> [server] [--public] for every project,
+// the project can be shortened to a unique prefix
+func handleSandboxCommand(command slack.SlashCommand, client *slack.Client) error {
+	user, err := client.GetUserInfo(command.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	public := false
+	var words []string
+	for _, word := range strings.Fields(html.UnescapeString(normalizeText(command.Text))) {
+		if strings.EqualFold(word, sandboxPublicFlag) {
+			public = true
+			continue
+		}
+		words = append(words, word)
+	}
+
+	usage := slack.Attachment{
+		Text:   fmt.Sprintf("Hi <@%s>, use `/sandbox status <project>`, `/sandbox claim <project> <server> [for 2h] [reason]` , `/sandbox release <project> <server>`, `/sandbox deploy <project> <server> fe=<branch> be=<branch>`, `/sandbox wait <project>`, `/sandbox leave <project>` or `/sandbox report <project> [week|month]`, add `%s` to answer in the channel", user.ID, sandboxPublicFlag),
+		Color:  "#563a9b",
+		Footer: "GRIP Release Bot",
+	}
+	if len(words) == 0 {
+		return postSlashReply(client, command, usage, true)
+	}
+	name, ok := sandboxSubcommands[strings.ToLower(words[0])]
+	if !ok {
+		return postSlashReply(client, command, usage, true)
+	}
+
+	input := ""
+	if len(words) > 1 {
+		input = words[1]
+	}
+	project, candidates := completeSandboxProject(input)
+	if project == "" {
+		if name == sandboxSubcommands["status"] && len(candidates) > 0 {
+			_, err := client.PostEphemeral(command.ChannelID, command.UserID, slack.MsgOptionText("Pick a sandbox project", false), slack.MsgOptionBlocks(sandboxProjectPicker(candidates, public)...))
+			return err
+		}
+		return postSlashReply(client, command, slack.Attachment{
+			Text:   fmt.Sprintf("Sorry <@%s>, I don't know the sandbox project '%s', try one of: %s", user.ID, input, strings.Join(candidates, ", ")),
+			Color:  "#e20228",
+			Footer: "GRIP Release Bot cannot continue",
+		}, true)
+	}
+
+	text := name + " " + project
+	if len(words) > 2 {
+		// claim take the duration and reason, deploy the branches, report the period, after the server
+		text += "," + strings.Join(words[2:], " ")
+	}
+
+	attachment, _ := botCommands.Dispatch(&CommandRequest{
+		Client:  client,
+		User:    user,
+		Channel: command.ChannelID,
+		Text:    text,
+		// a later message like the deploy result stay as private as the reply
+		Post: slashPost(client, command, !public),
+	})
+	if attachment.Text == "" {
+		return nil
+	}
+	return postSlashReply(client, command, attachment, !public || attachment.Color == "#e20228")
+}
+
+// sandboxProjectPicker let the user pick the project of /sandbox status, the value carry the public flag
+func sandboxProjectPicker(projects []string, public bool) []slack.Block {
+	var options []*slack.OptionBlockObject
+	for _, project := range projects {
+		value := project
+		if public {
+			value += " " + sandboxPublicFlag
+		}
+		options = append(options, slack.NewOptionBlockObject(value, slack.NewTextBlockObject(slack.PlainTextType, project, false, false), nil))
+	}
+
+	return []slack.Block{
+		slack.NewSectionBlock(
+			slack.NewTextBlockObject(slack.MarkdownType, "Which project do you want the sandbox status of?", false, false),
+			nil,
+			slack.NewAccessory(slack.NewOptionsSelectBlockElement(slack.OptTypeStatic, slack.NewTextBlockObject(slack.PlainTextType, "Select a project", false, false), actionSandboxStatusProject, options...)),
+		),
+	}
+}
+
+// handleSandboxStatusPick answer the project picker by running /sandbox status for the selected project
+func handleSandboxStatusPick(callback slack.InteractionCallback, action *slack.BlockAction, client *slack.Client) error {
+	return handleSandboxCommand(slack.SlashCommand{
+		Command:   "/sandbox",
+		Text:      "status " + action.SelectedOption.Value,
+		UserID:    callback.User.ID,
+		ChannelID: callback.Channel.ID,
+	}, client)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestReleaseSubcommandText(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    string
+		command string
+		ok      bool
+	}{
+		{"now grip <<1.2.0>>", "release grip <<1.2.0>>", "release", true},
+		{"NOW grip <<1.2.0>>", "release grip <<1.2.0>>", "release", true},
+		{"  now   grip  ", "release grip", "release", true},
+		{"schedule grip <<1.2.0>> at 17:00", "schedule release grip <<1.2.0>> at 17:00", "schedule release", true},
+		{"list", "active schedule", "active schedule", true},
+		{"cancel 6f1c", "remove schedule 6f1c", "remove schedule", true},
+		{"projects", "project list", "project list", true},
+		{"projects list", "project list", "project list", true},
+		{"projects add grip|token|jenkins.host", "add project grip|token|jenkins.host", "add project", true},
+		{"projects Delete grip", "delete project grip", "delete project", true},
+		{"access", "access list", "access list", true},
+		{"access LIST", "access list", "access list", true},
+		{"access add U0USER-Someone", "add access U0USER-Someone", "add access", true},
+		{"access disable U0USER", "disable access U0USER", "disable access", true},
+		{"access test", "test access", "test access", true},
+
+		{"", "", "", false},
+		{"deploy grip", "", "", false},
+		{"access grant U0USER admin", "", "", false},
+		{"projects rename grip", "", "", false},
+	}
+
+	for _, test := range tests {
+		got, ok := releaseSubcommandText(test.text)
+		if got != test.want || ok != test.ok {
+			t.Errorf("releaseSubcommandText(%q) = %q, %v, want %q, %v", test.text, got, ok, test.want, test.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		// the mapped text must run the intended mention command
+		command, _ := botCommands.Match(got)
+		if command == nil || command.Name != test.command {
+			t.Errorf("releaseSubcommandText(%q) = %q does not run %q", test.text, got, test.command)
+		}
+	}
+}
+
+func TestReleaseReplyPrivate(t *testing.T) {
+	tests := []struct {
+		text  string
+		color string
+		want  bool
+	}{
+		{"test project grip", "", true},
+		{"project list", "", false},
+		{"active schedule", "#4af030", false},
+		{"project list", "#e20228", true},
+	}
+
+	for _, test := range tests {
+		if got := releaseReplyPrivate(test.text, slack.Attachment{Text: "reply", Color: test.color}); got != test.want {
+			t.Errorf("releaseReplyPrivate(%q, %q) = %v, want %v", test.text, test.color, got, test.want)
+		}
+	}
+}
+
+func TestProjectListHideJenkinsToken(t *testing.T) {
+	useFakeDB(t, func(query string, args []driver.Value) fakeResult {
+		if strings.HasPrefix(query, "SELECT project_name, status, jenkins_token") {
+			return fakeResult{rows: [][]driver.Value{
+				{"grip", true, "GRIPTOKEN", "jenkins.example.com", "", "jenkins"},
+				{"web", true, "", "", "Asia/Jakarta", "github"},
+			}}
+		}
+		return fakeResult{}
+	})
+
+	command, _ := botCommands.Match("project list")
+	attachment := command.Handler(&CommandRequest{User: &slack.User{ID: "U0TEST"}})
+	if strings.Contains(attachment.Text, "GRIPTOKEN") || !strings.Contains(attachment.Text, "*grip* : [redacted] - jenkins.example.com") {
+		t.Errorf("project list = %q, want the token hidden", attachment.Text)
+	}
+}
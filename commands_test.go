@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// unavailableDriver fail every connection, the models then behave like for a user that is not in any table
+type unavailableDriver struct{}
+
+func (unavailableDriver) Open(name string) (driver.Conn, error) {
+	return nil, errors.New("no database in tests")
+}
+
+func TestMain(m *testing.M) {
+	sql.Register("unavailable", unavailableDriver{})
+	db, err := sql.Open("unavailable", "")
+	if err != nil {
+		panic(err.Error())
+	}
+	models.DB = db
+	log.SetOutput(io.Discard)
+
+	os.Exit(m.Run())
+}
+
+// fakeResult is the answer of a fakeHandler, rows for a query and affected for an exec
+type fakeResult struct {
+	rows     [][]driver.Value
+	affected int64
+	err      error
+}
+
+// fakeHandler answer every query the models send, matched on the SQL text
+type fakeHandler func(query string, args []driver.Value) fakeResult
+
+// useFakeDB point the models to handle for the test, the unavailable database is back once the test end
+func useFakeDB(t *testing.T, handle fakeHandler) {
+	previous := models.DB
+	models.DB = sql.OpenDB(fakeConnector{handle})
+	t.Cleanup(func() {
+		models.DB.Close()
+		models.DB = previous
+	})
+}
+
+type fakeConnector struct {
+	handle fakeHandler
+}
+
+func (c fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return fakeConn{c.handle}, nil
+}
+
+func (c fakeConnector) Driver() driver.Driver {
+	return unavailableDriver{}
+}
+
+// fakeConn run the statements directly so database/sql never prepare them
+type fakeConn struct {
+	handle fakeHandler
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported by the fake database")
+}
+
+func (c fakeConn) Close() error {
+	return nil
+}
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported by the fake database")
+}
+
+func (c fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result := c.handle(query, fakeValues(args))
+	if result.err != nil {
+		return nil, result.err
+	}
+	return driver.RowsAffected(result.affected), nil
+}
+
+func (c fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result := c.handle(query, fakeValues(args))
+	if result.err != nil {
+		return nil, result.err
+	}
+	return &fakeRows{rows: result.rows}, nil
+}
+
+func fakeValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+// Columns only tell database/sql how many values a row has, the models scan by position
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func testCommand(name string, permission Permission, args string, handler func(req *CommandRequest) slack.Attachment) *Command {
+	command := &Command{Name: name, Permission: permission, Handler: handler}
+	if args != "" {
+		command.Args = regexp.MustCompile(args)
+	}
+	return command
+}
+
+func testRegistry() *CommandRegistry {
+	reply := func(req *CommandRequest) slack.Attachment { return slack.Attachment{Text: "ok"} }
+	return NewCommandRegistry(
+		testCommand("release", PermissionNone, "", reply),
+		testCommand("release status", PermissionNone, "", reply),
+		testCommand("schedule release", PermissionNone, "", reply),
+		testCommand("help", PermissionNone, "", reply),
+		testCommand("set project deployer", PermissionNone, "", reply),
+	)
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		text    string
+		command string
+		args    string
+	}{
+		{"release grip", "release", "grip"},
+		{"release status", "release status", ""},
+		{"release status grip now", "release status", "grip now"},
+		{"RELEASE Status grip", "release status", "grip"},
+		{"Schedule release grip 17:00", "schedule release", "grip 17:00"},
+		{"help", "help", ""},
+		{"  release   status  grip  now ", "release status", "grip  now"},
+		{"set project deployer grip webhook {\"body\": \"v={{ .Version }}  &x=1\"}", "set project deployer", "grip webhook {\"body\": \"v={{ .Version }}  &x=1\"}"},
+		{"set project deployer grip webhook\n{\n  \"url\": \"https://ci\"\n}", "set project deployer", "grip webhook\n{\n  \"url\": \"https://ci\"\n}"},
+		{"releases", "", ""},
+		{"schedule", "", ""},
+		{"status release", "", ""},
+		{"", "", ""},
+	}
+
+	registry := testRegistry()
+	for _, test := range tests {
+		command, args := registry.Match(test.text)
+		name := ""
+		if command != nil {
+			name = command.Name
+		}
+		if name != test.command || args != test.args {
+			t.Errorf("Match(%q) = %q, %q, want %q, %q", test.text, name, args, test.command, test.args)
+		}
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	var handled *CommandRequest
+	handler := func(req *CommandRequest) slack.Attachment {
+		handled = req
+		return slack.Attachment{Text: "done"}
+	}
+	deploy := testCommand("deploy", PermissionNone, `^([^,]+),(.*)$`, handler)
+	deploy.Usage = "deploy [project], [branch]"
+	deploy.Example = "deploy grip, main"
+	registry := NewCommandRegistry(
+		deploy,
+		testCommand("add access", PermissionAdmin, "", handler),
+		testCommand("ping", PermissionNone, "", handler),
+	)
+
+	tests := []struct {
+		name    string
+		text    string
+		matched bool
+		handled bool
+		reply   string
+		args    []string
+	}{
+		{name: "no match", text: "hello there"},
+		{name: "permission denied", text: "add access U123", matched: true, reply: "you don't have the permission"},
+		{name: "usage mismatch", text: "deploy grip main", matched: true, reply: "make sure the format is 'deploy [project], [branch]'"},
+		{name: "args trimmed", text: "deploy  grip ,  main ", matched: true, handled: true, reply: "done", args: []string{"grip", "main"}},
+		{name: "no grammar", text: "ping  a  b", matched: true, handled: true, reply: "done"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handled = nil
+			attachment, ok := registry.Dispatch(&CommandRequest{User: &slack.User{ID: "U0TEST"}, Channel: "C0TEST", Text: test.text})
+			if ok != test.matched {
+				t.Fatalf("Dispatch(%q) matched = %v, want %v", test.text, ok, test.matched)
+			}
+			if (handled != nil) != test.handled {
+				t.Fatalf("Dispatch(%q) handled = %v, want %v", test.text, handled != nil, test.handled)
+			}
+			if !strings.Contains(attachment.Text, test.reply) {
+				t.Errorf("Dispatch(%q) reply = %q, want it to contain %q", test.text, attachment.Text, test.reply)
+			}
+			if test.args != nil {
+				for i, arg := range test.args {
+					if handled.Args[i+1] != arg {
+						t.Errorf("Dispatch(%q) Args[%d] = %q, want %q", test.text, i+1, handled.Args[i+1], arg)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDispatchUsageExample(t *testing.T) {
+	command := testCommand("deploy", PermissionNone, `^(\S+)$`, func(req *CommandRequest) slack.Attachment { return slack.Attachment{} })
+	command.Example = "deploy grip"
+
+	attachment, _ := NewCommandRegistry(command).Dispatch(&CommandRequest{User: &slack.User{ID: "U0TEST"}, Text: "deploy"})
+	if attachment.Footer != "Example: deploy grip" {
+		t.Errorf("footer = %q, want the example", attachment.Footer)
+	}
+}
+
+func TestStripMention(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"<@U0BOT> release grip", "release grip"},
+		{"<@U0BOT>release grip", "release grip"},
+		{"  <@U0BOT>   help  ", "help"},
+		{"<@U0BOT> <@U0OTHER> help", "help"},
+		{"release <@U0BOT>", "release <@U0BOT>"},
+		{"add access <@U0USER|someone>", "add access <@U0USER|someone>"},
+		{"<@U0BOT>", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if got := stripMention(test.text); got != test.want {
+			t.Errorf("stripMention(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
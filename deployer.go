@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stevenfamy/go-slackbot-release/config"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// Deployer start a release on the CI backend of a project
+type Deployer interface {
+	// Name is the backend name shown to the user, e.g. jenkins
+	Name() string
+	// Deploy start the release, a backend that cannot be followed return an acceptedDeployment
+	Deploy(ctx context.Context, release DeployRequest) (Deployment, error)
+}
+
+// Deployment is a started release the bot can follow until it finish
+type Deployment interface {
+	// Description identify the deployment, e.g. the Jenkins queue url, it is kept in the release history
+	Description() string
+	// Wait block until the deployment finish, onStart is called once it is running
+	Wait(ctx context.Context, onStart func(DeployStatus)) (DeployStatus, error)
+}
+
+// DeployRequest is what every backend receive to release a project
+type DeployRequest struct {
+	ReleaseId  string
+	Project    string
+	Version    string
+	IsSchedule bool
+	ReleaseAt  string
+	// Testing is true outside production so the pipeline can do a dry run
+	Testing bool
+}
+
+// DeployStatus is the progress or the result of a deployment
+type DeployStatus struct {
+	// Name is the build or pipeline identifier, e.g. #12
+	Name      string
+	URL       string
+	Result    string
+	Succeeded bool
+	Duration  time.Duration
+	// Accepted is set when the backend only accepted the release, the result is not known to the bot
+	Accepted bool
+}
+
+// acceptedDeployment is the deployment of a backend that cannot be followed, it finish as soon as the
+// backend accepted the release so the release does not stay triggered
+type acceptedDeployment struct {
+	description string
+	status      DeployStatus
+}
+
+func (d *acceptedDeployment) Description() string {
+	return d.description
+}
+
+func (d *acceptedDeployment) Wait(ctx context.Context, onStart func(DeployStatus)) (DeployStatus, error) {
+	status := d.status
+	status.Accepted = true
+	status.Succeeded = true
+	return status, nil
+}
+
+// Deployer kinds stored in projects.deployer
+const (
+	DeployerJenkins = "jenkins"
+	DeployerGitlab  = "gitlab"
+	DeployerGithub  = "github"
+	DeployerWebhook = "webhook"
+)
+
+// DeployerKinds is the accepted value of projects.deployer
+var DeployerKinds = []string{DeployerJenkins, DeployerGitlab, DeployerGithub, DeployerWebhook}
+
+// deployerHTTP is shared by the backends, a deploy call should never hang the ticker
+var deployerHTTP = &http.Client{Timeout: 30 * time.Second}
+
+// deployerPollInterval is how often the gitlab and github deployments are polled until they finish
+var deployerPollInterval = 10 * time.Second
+
+// DeployerForProject build the deployer configured for the project, jenkins use the jenkins_host and
+// jenkins_token column while the other backends read their JSON config from projects.deployer_config
+func DeployerForProject(project string) (Deployer, error) {
+	kind, deployerConfig := models.GetProjectDeployer(project)
+
+	switch kind {
+	case "", DeployerJenkins:
+		return &jenkinsDeployer{
+			host:  models.GetProjectJenkinsHost(project),
+			token: models.GetProjectToken(project),
+		}, nil
+	case DeployerGitlab:
+		deployer := &gitlabDeployer{}
+		return deployer, decodeDeployerConfig(kind, project, deployerConfig, deployer)
+	case DeployerGithub:
+		deployer := &githubDeployer{}
+		return deployer, decodeDeployerConfig(kind, project, deployerConfig, deployer)
+	case DeployerWebhook:
+		deployer := &webhookDeployer{}
+		return deployer, decodeDeployerConfig(kind, project, deployerConfig, deployer)
+	}
+
+	return nil, fmt.Errorf("project %s use unknown deployer %q", project, kind)
+}
+
+// ValidateDeployerConfig check the JSON config of the project deployer before it is saved
+func ValidateDeployerConfig(kind string, project string, deployerConfig string) error {
+	switch kind {
+	case DeployerJenkins:
+		return nil
+	case DeployerGitlab:
+		return decodeDeployerConfig(kind, project, deployerConfig, &gitlabDeployer{})
+	case DeployerGithub:
+		return decodeDeployerConfig(kind, project, deployerConfig, &githubDeployer{})
+	case DeployerWebhook:
+		return decodeDeployerConfig(kind, project, deployerConfig, &webhookDeployer{})
+	}
+	return fmt.Errorf("unknown deployer %q", kind)
+}
+
+// deployerEnvPrefix is the prefix of the bot environment variables a project deployer can read,
+// e.g. DEPLOY_LOGISTICS_BACKEND_ for logistics-backend
+func deployerEnvPrefix(project string) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(project))
+	return "DEPLOY_" + name + "_"
+}
+
+// deployerSecret read a deployer credential from the bot environment by its name, so the token itself is never
+// typed in Slack nor stored in projects.deployer_config. Only the variables with the project prefix can be read,
+// a project admin cannot send the bot own secrets or the one of another project to a CI backend
+func deployerSecret(project string, field string, name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("%s is required", field)
+	}
+	if prefix := deployerEnvPrefix(project); !strings.HasPrefix(name, prefix) || name == prefix {
+		return "", fmt.Errorf("%s %s must start with %s, a deployer only read the variables of its project", field, name, prefix)
+	}
+	value := config.GetConfig(name)
+	if value == "" {
+		return "", fmt.Errorf("%s %s is not set in the bot environment", field, name)
+	}
+	return value, nil
+}
+
+type validator interface {
+	validate(project string) error
+}
+
+func decodeDeployerConfig(kind string, project string, deployerConfig string, deployer validator) error {
+	if err := json.Unmarshal([]byte(deployerConfig), deployer); err != nil {
+		return fmt.Errorf("invalid %s deployer config: %w", kind, err)
+	}
+	if err := deployer.validate(project); err != nil {
+		return fmt.Errorf("invalid %s deployer config: %w", kind, err)
+	}
+	return nil
+}
+
+// newDeployRequest fill the request the same way for every backend
+func newDeployRequest(releaseSchedule models.ReleaseSchedule) DeployRequest {
+	return DeployRequest{
+		ReleaseId:  releaseSchedule.Id,
+		Project:    releaseSchedule.ReleaseProject,
+		Version:    releaseSchedule.ReleaseVersion,
+		IsSchedule: false,
+		ReleaseAt:  "0000",
+		Testing:    config.GetConfig("ENVIRONMENT") != "production",
+	}
+}
+
+// doJSON send the request and decode the JSON response when value is not nil
+func doJSON(req *http.Request, expected int, value any) error {
+	resp, err := deployerHTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expected {
+		return &statusError{Target: req.Method + " " + req.URL.Redacted(), Status: resp.Status, StatusCode: resp.StatusCode}
+	}
+	if value == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(value)
+}
+
+// statusError is a CI backend call answered with another status than the expected one
+type statusError struct {
+	Target     string
+	Status     string
+	StatusCode int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s responded %s", e.Target, e.Status)
+}
+
+// retryable tell if a failed poll can be tried again, a network error or a 5xx while the backend restart
+// should not fail a deployment that is still running, only a client error like a missing build or bad credentials is final
+func retryable(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// pollDeployment call poll every interval until it is done, poll return done with an error to stop on a final error.
+// Any other failed poll is tried again until ctx expire when it is retryable, the timeout error is then what
+// did not finish with the last poll error
+func pollDeployment(ctx context.Context, interval time.Duration, poll func() (bool, error), what func() string) error {
+	for {
+		done, pollErr := poll()
+		if done || (pollErr != nil && !retryable(pollErr)) {
+			return pollErr
+		}
+
+		if err := sleepContext(ctx, interval); err != nil {
+			if pollErr != nil {
+				return fmt.Errorf("%s, last poll failed with %s: %w", what(), pollErr.Error(), err)
+			}
+			return fmt.Errorf("%s: %w", what(), err)
+		}
+	}
+}
+
+func sleepContext(ctx context.Context, wait time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
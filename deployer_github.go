@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// githubDeployer run a workflow_dispatch workflow, the version is sent as the VersionInput input and the
+// release id as the DispatchInput input, the dry run flag only when TestInput is set. The workflow must put the
+// release id in its run-name, e.g. run-name: release ${{ inputs.version }} ${{ inputs.release_id }},
+// it is how the bot find the run it started
+type githubDeployer struct {
+	// BaseURL default to https://api.github.com
+	BaseURL string `json:"base_url"`
+	Owner   string `json:"owner"`
+	Repo    string `json:"repo"`
+	// Workflow is the workflow file name or id, e.g. release.yml
+	Workflow string `json:"workflow"`
+	Ref      string `json:"ref"`
+	// TokenEnv is the name of the bot environment variable holding the token, e.g. DEPLOY_LOGISTICS_WEB_GITHUB_TOKEN
+	TokenEnv string `json:"token_env"`
+	// VersionInput default to version
+	VersionInput string `json:"version_input"`
+	// DispatchInput default to release_id
+	DispatchInput string `json:"dispatch_input"`
+	// TestInput is the boolean input told when the release is a dry run, the workflow must declare it
+	TestInput string `json:"test_input"`
+
+	token string
+}
+
+// githubRunLookup is how long after the dispatch the run must show up with the release id in its name
+const githubRunLookup = 5 * time.Minute
+
+func (d *githubDeployer) Name() string {
+	return DeployerGithub
+}
+
+func (d *githubDeployer) validate(project string) error {
+	if d.Owner == "" || d.Repo == "" || d.Workflow == "" || d.Ref == "" {
+		return errors.New("owner, repo, workflow, ref and token_env are required")
+	}
+	token, err := deployerSecret(project, "token_env", d.TokenEnv)
+	if err != nil {
+		return err
+	}
+	d.token = token
+	return nil
+}
+
+func (d *githubDeployer) dispatchInput() string {
+	if d.DispatchInput == "" {
+		return "release_id"
+	}
+	return d.DispatchInput
+}
+
+func (d *githubDeployer) repoURL() string {
+	baseURL := d.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.github.com"
+	}
+	return fmt.Sprintf("%s/repos/%s/%s", strings.TrimSuffix(baseURL, "/"), url.PathEscape(d.Owner), url.PathEscape(d.Repo))
+}
+
+func (d *githubDeployer) workflowURL() string {
+	return d.repoURL() + "/actions/workflows/" + url.PathEscape(d.Workflow)
+}
+
+func (d *githubDeployer) newRequest(ctx context.Context, method string, target string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+d.token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	return req, nil
+}
+
+func (d *githubDeployer) Deploy(ctx context.Context, release DeployRequest) (Deployment, error) {
+	versionInput := d.VersionInput
+	if versionInput == "" {
+		versionInput = "version"
+	}
+	// GitHub refuse the dispatch when an input is not declared by the workflow, only the configured one are sent
+	inputs := map[string]string{
+		versionInput:      release.Version,
+		d.dispatchInput(): release.ReleaseId,
+	}
+	if d.TestInput != "" {
+		inputs[d.TestInput] = strconv.FormatBool(release.Testing)
+	}
+	body, _ := json.Marshal(map[string]any{
+		"ref":    d.Ref,
+		"inputs": inputs,
+	})
+
+	// the dispatch does not return the run, the runs created since this moment, a minute early for
+	// a clock skew with GitHub, are searched for the one with the release id in its name
+	dispatchedAt := time.Now().UTC()
+
+	req, err := d.newRequest(ctx, http.MethodPost, d.workflowURL()+"/dispatches", body)
+	if err != nil {
+		return nil, err
+	}
+	if err := doJSON(req, http.StatusNoContent, nil); err != nil {
+		return nil, fmt.Errorf("github workflow dispatch failed: %w", err)
+	}
+
+	return &githubDeployment{deployer: d, dispatchId: release.ReleaseId, dispatchedAt: dispatchedAt}, nil
+}
+
+type githubRun struct {
+	Id        int    `json:"id"`
+	RunNumber int    `json:"run_number"`
+	HTMLURL   string `json:"html_url"`
+	// DisplayTitle is the run-name of the workflow
+	DisplayTitle string    `json:"display_title"`
+	Status       string    `json:"status"`
+	Conclusion   string    `json:"conclusion"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	StartedAt    time.Time `json:"run_started_at"`
+}
+
+type githubDeployment struct {
+	deployer     *githubDeployer
+	dispatchId   string
+	dispatchedAt time.Time
+}
+
+func (d *githubDeployment) Description() string {
+	return fmt.Sprintf("%s/%s %s on %s", d.deployer.Owner, d.deployer.Repo, d.deployer.Workflow, d.deployer.Ref)
+}
+
+func (d *githubDeployment) Wait(ctx context.Context, onStart func(DeployStatus)) (DeployStatus, error) {
+	var status DeployStatus
+	runURL := ""
+	started := false
+	err := pollDeployment(ctx, deployerPollInterval, func() (bool, error) {
+		var run githubRun
+		if runURL == "" {
+			found, err := d.findRun(ctx)
+			if err != nil {
+				return false, err
+			}
+			if found.Id == 0 {
+				if time.Since(d.dispatchedAt) > githubRunLookup {
+					return true, fmt.Errorf("no run of %s has the release id %s in its name, add ${{ inputs.%s }} to the workflow run-name", d.deployer.Workflow, d.dispatchId, d.deployer.dispatchInput())
+				}
+				return false, nil
+			}
+			run = found
+			runURL = fmt.Sprintf("%s/actions/runs/%d", d.deployer.repoURL(), run.Id)
+		} else {
+			req, err := d.deployer.newRequest(ctx, http.MethodGet, runURL, nil)
+			if err != nil {
+				return true, err
+			}
+			if err := doJSON(req, http.StatusOK, &run); err != nil {
+				return false, err
+			}
+		}
+
+		status = DeployStatus{
+			Name:   fmt.Sprintf("run #%d", run.RunNumber),
+			URL:    run.HTMLURL,
+			Result: run.Conclusion,
+		}
+		if !run.StartedAt.IsZero() {
+			status.Duration = run.UpdatedAt.Sub(run.StartedAt)
+		}
+		if !started {
+			onStart(status)
+			started = true
+		}
+		status.Succeeded = run.Status == "completed" && run.Conclusion == "success"
+		return run.Status == "completed", nil
+	}, func() string {
+		return "workflow run did not finish in time"
+	})
+	return status, err
+}
+
+// findRun return the workflow_dispatch run with the release id in its name, zero when not created yet
+func (d *githubDeployment) findRun(ctx context.Context) (githubRun, error) {
+	query := url.Values{}
+	query.Set("event", "workflow_dispatch")
+	query.Set("branch", d.deployer.Ref)
+	query.Set("created", ">="+d.dispatchedAt.Add(-time.Minute).Format(time.RFC3339))
+	query.Set("per_page", "100")
+
+	req, err := d.deployer.newRequest(ctx, http.MethodGet, d.deployer.workflowURL()+"/runs?"+query.Encode(), nil)
+	if err != nil {
+		return githubRun{}, err
+	}
+
+	var body struct {
+		WorkflowRuns []githubRun `json:"workflow_runs"`
+	}
+	if err := doJSON(req, http.StatusOK, &body); err != nil {
+		return githubRun{}, err
+	}
+
+	for _, run := range body.WorkflowRuns {
+		if strings.Contains(run.DisplayTitle, d.dispatchId) {
+			return run, nil
+		}
+	}
+	return githubRun{}, nil
+}
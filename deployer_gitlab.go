@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gitlabDeployer run a pipeline with a pipeline trigger token, the pipeline is followed when APITokenEnv is set.
+// The tokens are read from the bot environment variables named in the config, see deployerSecret
+type gitlabDeployer struct {
+	// BaseURL default to https://gitlab.com
+	BaseURL string `json:"base_url"`
+	// ProjectId is the numeric id or the url encoded path of the project
+	ProjectId       string `json:"project_id"`
+	TriggerTokenEnv string `json:"trigger_token_env"`
+	Ref             string `json:"ref"`
+	// APITokenEnv hold a read_api token used to follow the pipeline, optional
+	APITokenEnv string `json:"api_token_env"`
+
+	triggerToken string
+	apiToken     string
+}
+
+func (d *gitlabDeployer) Name() string {
+	return DeployerGitlab
+}
+
+func (d *gitlabDeployer) validate(project string) error {
+	if d.ProjectId == "" || d.Ref == "" {
+		return errors.New("project_id, trigger_token_env and ref are required")
+	}
+	token, err := deployerSecret(project, "trigger_token_env", d.TriggerTokenEnv)
+	if err != nil {
+		return err
+	}
+	d.triggerToken = token
+	if d.APITokenEnv != "" {
+		if d.apiToken, err = deployerSecret(project, "api_token_env", d.APITokenEnv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *gitlabDeployer) baseURL() string {
+	if d.BaseURL == "" {
+		return "https://gitlab.com"
+	}
+	return strings.TrimSuffix(d.BaseURL, "/")
+}
+
+func (d *gitlabDeployer) Deploy(ctx context.Context, release DeployRequest) (Deployment, error) {
+	form := url.Values{}
+	form.Set("token", d.triggerToken)
+	form.Set("ref", d.Ref)
+	form.Set("variables[RELEASE_VERSION]", release.Version)
+	form.Set("variables[RELEASE_PROJECT]", release.Project)
+	form.Set("variables[RELEASE_ID]", release.ReleaseId)
+	form.Set("variables[TEST_RELEASE]", strconv.FormatBool(release.Testing))
+
+	target := fmt.Sprintf("%s/api/v4/projects/%s/trigger/pipeline", d.baseURL(), url.PathEscape(d.ProjectId))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var pipeline gitlabPipeline
+	if err := doJSON(req, http.StatusCreated, &pipeline); err != nil {
+		return nil, fmt.Errorf("gitlab pipeline trigger failed: %w", err)
+	}
+
+	if d.apiToken == "" {
+		// without a read_api token the pipeline cannot be followed
+		return &acceptedDeployment{
+			description: pipeline.WebURL,
+			status:      DeployStatus{Name: fmt.Sprintf("pipeline #%d", pipeline.Id), URL: pipeline.WebURL, Result: pipeline.Status},
+		}, nil
+	}
+	return &gitlabDeployment{deployer: d, pipeline: pipeline}, nil
+}
+
+type gitlabPipeline struct {
+	Id        int        `json:"id"`
+	Status    string     `json:"status"`
+	WebURL    string     `json:"web_url"`
+	StartedAt *time.Time `json:"started_at"`
+	Duration  float64    `json:"duration"`
+}
+
+type gitlabDeployment struct {
+	deployer *gitlabDeployer
+	pipeline gitlabPipeline
+}
+
+func (d *gitlabDeployment) Description() string {
+	return d.pipeline.WebURL
+}
+
+func (d *gitlabDeployment) Wait(ctx context.Context, onStart func(DeployStatus)) (DeployStatus, error) {
+	target := fmt.Sprintf("%s/api/v4/projects/%s/pipelines/%d", d.deployer.baseURL(), url.PathEscape(d.deployer.ProjectId), d.pipeline.Id)
+	var status DeployStatus
+	started := false
+	err := pollDeployment(ctx, deployerPollInterval, func() (bool, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return true, err
+		}
+		req.Header.Set("PRIVATE-TOKEN", d.deployer.apiToken)
+
+		var pipeline gitlabPipeline
+		if err := doJSON(req, http.StatusOK, &pipeline); err != nil {
+			return false, err
+		}
+		status = DeployStatus{
+			Name:     fmt.Sprintf("pipeline #%d", pipeline.Id),
+			URL:      pipeline.WebURL,
+			Result:   pipeline.Status,
+			Duration: time.Duration(pipeline.Duration * float64(time.Second)),
+		}
+
+		switch pipeline.Status {
+		case "success":
+			status.Succeeded = true
+			return true, nil
+		case "failed", "canceled", "skipped":
+			return true, nil
+		case "running":
+			if !started {
+				onStart(status)
+				started = true
+			}
+		}
+		return false, nil
+	}, func() string {
+		return fmt.Sprintf("pipeline #%d did not finish in time", d.pipeline.Id)
+	})
+	return status, err
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+)
+
+// jenkinsDeployer trigger the generic webhook trigger job of the project
+type jenkinsDeployer struct {
+	host  string
+	token string
+}
+
+func (d *jenkinsDeployer) Name() string {
+	return DeployerJenkins
+}
+
+func (d *jenkinsDeployer) validate() error {
+	if d.host == "" || d.token == "" {
+		return errors.New("jenkins host and token are required")
+	}
+	return nil
+}
+
+func (d *jenkinsDeployer) Deploy(ctx context.Context, release DeployRequest) (Deployment, error) {
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+	log.Print("jenkinsAddress", d.host)
+	log.Print("isTesting", release.Testing)
+
+	params := url.Values{}
+	params.Set("token", d.token)
+	params.Set("buildEnv", "production")
+	params.Set("release_version", release.Version)
+	params.Set("project_id", "null")
+	params.Set("release_id", "null")
+	params.Set("release_timer", strconv.FormatBool(release.IsSchedule))
+	params.Set("release_at", release.ReleaseAt)
+	params.Set("test_release", strconv.FormatBool(release.Testing))
+
+	jenkins := NewJenkinsClient(d.host)
+	trigger, err := jenkins.TriggerWebhook(params)
+	if err != nil {
+		return nil, err
+	}
+	return &jenkinsDeployment{jenkins: jenkins, trigger: trigger}, nil
+}
+
+type jenkinsDeployment struct {
+	jenkins *JenkinsClient
+	trigger *JenkinsTrigger
+}
+
+func (d *jenkinsDeployment) Description() string {
+	return d.trigger.QueueURL
+}
+
+func (d *jenkinsDeployment) Wait(ctx context.Context, onStart func(DeployStatus)) (DeployStatus, error) {
+	build, err := d.jenkins.WaitForBuild(ctx, d.trigger, func(build JenkinsBuild) {
+		onStart(jenkinsStatus(build))
+	})
+	if err != nil {
+		return DeployStatus{}, err
+	}
+	return jenkinsStatus(build), nil
+}
+
+func jenkinsStatus(build JenkinsBuild) DeployStatus {
+	return DeployStatus{
+		Name:      fmt.Sprintf("#%d", build.Number),
+		URL:       build.URL,
+		Result:    build.Result,
+		Succeeded: build.Result == "SUCCESS",
+		Duration:  build.Duration,
+	}
+}
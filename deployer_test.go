@@ -0,0 +1,454 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type backendResponse struct {
+	status int
+	body   string
+}
+
+func backendOk(body string) backendResponse {
+	return backendResponse{status: http.StatusOK, body: body}
+}
+
+// fakeBackend answer each "METHOD /path" with its scripted responses in order, the last one is repeated,
+// and keep the request bodies so a test can check what was sent
+type fakeBackend struct {
+	mu        sync.Mutex
+	responses map[string][]backendResponse
+	requests  map[string][]*http.Request
+	bodies    map[string][]string
+}
+
+func newFakeBackend(responses map[string][]backendResponse) *fakeBackend {
+	return &fakeBackend{responses: responses, requests: map[string][]*http.Request{}, bodies: map[string][]string{}}
+}
+
+func (f *fakeBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.Method + " " + r.URL.Path
+	body, _ := io.ReadAll(r.Body)
+
+	f.mu.Lock()
+	f.requests[key] = append(f.requests[key], r)
+	f.bodies[key] = append(f.bodies[key], string(body))
+	response := backendResponse{status: http.StatusNotFound}
+	if scripted := f.responses[key]; len(scripted) > 0 {
+		response = scripted[0]
+		if len(scripted) > 1 {
+			f.responses[key] = scripted[1:]
+		}
+	}
+	f.mu.Unlock()
+
+	w.WriteHeader(response.status)
+	fmt.Fprint(w, response.body)
+}
+
+func (f *fakeBackend) sent(key string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.bodies[key]
+}
+
+// first return the first request sent to key, an empty one when there was none
+func (f *fakeBackend) first(key string) *http.Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.requests[key]) == 0 {
+		return &http.Request{Header: http.Header{}, URL: &url.URL{}}
+	}
+	return f.requests[key][0]
+}
+
+func fastDeployerPoll(t *testing.T) {
+	previous := deployerPollInterval
+	deployerPollInterval = time.Millisecond
+	t.Cleanup(func() { deployerPollInterval = previous })
+}
+
+func waitDeployment(t *testing.T, deployment Deployment, timeout time.Duration) (DeployStatus, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	started := 0
+	status, err := deployment.Wait(ctx, func(DeployStatus) { started++ })
+	return status, started, err
+}
+
+func TestDeployerSecret(t *testing.T) {
+	t.Setenv("DEPLOY_LOGISTICS_BACKEND_TOKEN", "s3cret")
+	t.Setenv("DEPLOY_GRIP_TOKEN", "other")
+	t.Setenv("SLACK_AUTH_TOKEN", "xoxb")
+
+	tests := []struct {
+		name  string
+		value string
+		err   string
+	}{
+		{"DEPLOY_LOGISTICS_BACKEND_TOKEN", "s3cret", ""},
+		{"", "", "is required"},
+		{"SLACK_AUTH_TOKEN", "", "must start with DEPLOY_LOGISTICS_BACKEND_"},
+		{"DEPLOY_GRIP_TOKEN", "", "must start with DEPLOY_LOGISTICS_BACKEND_"},
+		{"deploy_logistics_backend_token", "", "must start with"},
+		{"DEPLOY_LOGISTICS_BACKEND_", "", "must start with"},
+		{"DEPLOY_LOGISTICS_BACKEND_MISSING", "", "is not set"},
+	}
+
+	for _, test := range tests {
+		value, err := deployerSecret("logistics-backend", "token_env", test.name)
+		if test.err == "" {
+			if err != nil || value != test.value {
+				t.Errorf("deployerSecret(%q) = %q, %v, want %q", test.name, value, err, test.value)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("deployerSecret(%q) error = %v, want %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestGithubDeployer(t *testing.T) {
+	fastDeployerPoll(t)
+	t.Setenv("DEPLOY_GRIP_GITHUB_TOKEN", "ghp_test")
+
+	const dispatches = "POST /repos/stevenfamy/grip/actions/workflows/release.yml/dispatches"
+	const runs = "GET /repos/stevenfamy/grip/actions/workflows/release.yml/runs"
+	const run = "GET /repos/stevenfamy/grip/actions/runs/42"
+	listed := backendOk(`{"workflow_runs":[{"id":41,"display_title":"release 1.1.0 other-id"},{"id":42,"run_number":7,"html_url":"https://github.com/stevenfamy/grip/actions/runs/42","display_title":"release 1.2.0 r-1","status":"queued"}]}`)
+	running := backendOk(`{"id":42,"run_number":7,"html_url":"https://github.com/stevenfamy/grip/actions/runs/42","status":"in_progress"}`)
+
+	tests := []struct {
+		name        string
+		config      string
+		dispatch    backendResponse
+		runs        []backendResponse
+		run         []backendResponse
+		inputs      map[string]string
+		deployErr   string
+		succeeded   bool
+		result      string
+		duration    time.Duration
+		started     int
+		waitErr     string
+		waitTimeout time.Duration
+	}{
+		{
+			name:     "success after transient errors",
+			config:   `{}`,
+			dispatch: backendResponse{status: http.StatusNoContent},
+			runs:     []backendResponse{backendOk(`{"workflow_runs":[]}`), {status: http.StatusBadGateway}, listed},
+			run: []backendResponse{running, {status: http.StatusServiceUnavailable}, {status: http.StatusTooManyRequests},
+				backendOk(`{"id":42,"run_number":7,"status":"completed","conclusion":"success","run_started_at":"2026-10-18T10:00:00Z","updated_at":"2026-10-18T10:01:05Z"}`)},
+			inputs:    map[string]string{"version": "1.2.0", "release_id": "r-1"},
+			succeeded: true,
+			result:    "success",
+			duration:  65 * time.Second,
+			started:   1,
+		},
+		{
+			name:     "configured inputs",
+			config:   `{"version_input":"tag","dispatch_input":"bot_id","test_input":"dry_run"}`,
+			dispatch: backendResponse{status: http.StatusNoContent},
+			runs:     []backendResponse{listed},
+			run:      []backendResponse{backendOk(`{"id":42,"run_number":7,"status":"completed","conclusion":"failure"}`)},
+			inputs:   map[string]string{"tag": "1.2.0", "bot_id": "r-1", "dry_run": "true"},
+			result:   "failure",
+			started:  1,
+		},
+		{
+			name:      "dispatch refused",
+			config:    `{}`,
+			dispatch:  backendResponse{status: http.StatusUnprocessableEntity},
+			deployErr: "422",
+		},
+		{
+			name:     "missing run is final",
+			config:   `{}`,
+			dispatch: backendResponse{status: http.StatusNoContent},
+			runs:     []backendResponse{listed},
+			run:      []backendResponse{{status: http.StatusNotFound}},
+			inputs:   map[string]string{"version": "1.2.0", "release_id": "r-1"},
+			started:  1,
+			waitErr:  "404",
+		},
+		{
+			name:        "timeout while github is down",
+			config:      `{}`,
+			dispatch:    backendResponse{status: http.StatusNoContent},
+			runs:        []backendResponse{listed},
+			run:         []backendResponse{running, {status: http.StatusInternalServerError}},
+			inputs:      map[string]string{"version": "1.2.0", "release_id": "r-1"},
+			started:     1,
+			waitErr:     "last poll failed",
+			waitTimeout: 50 * time.Millisecond,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backend := newFakeBackend(map[string][]backendResponse{dispatches: {test.dispatch}, runs: test.runs, run: test.run})
+			server := httptest.NewServer(backend)
+			defer server.Close()
+
+			var config map[string]string
+			json.Unmarshal([]byte(test.config), &config)
+			config["base_url"] = server.URL
+			config["owner"], config["repo"], config["workflow"], config["ref"], config["token_env"] = "stevenfamy", "grip", "release.yml", "main", "DEPLOY_GRIP_GITHUB_TOKEN"
+			raw, _ := json.Marshal(config)
+
+			deployer := &githubDeployer{}
+			if err := decodeDeployerConfig(DeployerGithub, "grip", string(raw), deployer); err != nil {
+				t.Fatalf("config error: %s", err.Error())
+			}
+			deployment, err := deployer.Deploy(context.Background(), DeployRequest{ReleaseId: "r-1", Project: "grip", Version: "1.2.0", Testing: true})
+			if test.deployErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.deployErr) {
+					t.Fatalf("Deploy error = %v, want %q", err, test.deployErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Deploy error: %s", err.Error())
+			}
+
+			if got := backend.first(dispatches).Header.Get("Authorization"); got != "Bearer ghp_test" {
+				t.Errorf("dispatch Authorization = %q", got)
+			}
+			var dispatch struct {
+				Ref    string            `json:"ref"`
+				Inputs map[string]string `json:"inputs"`
+			}
+			json.Unmarshal([]byte(backend.sent(dispatches)[0]), &dispatch)
+			if dispatch.Ref != "main" || fmt.Sprint(dispatch.Inputs) != fmt.Sprint(test.inputs) {
+				t.Errorf("dispatch = %+v, want inputs %v", dispatch, test.inputs)
+			}
+
+			timeout := test.waitTimeout
+			if timeout == 0 {
+				timeout = 5 * time.Second
+			}
+			status, started, err := waitDeployment(t, deployment, timeout)
+			if started != test.started {
+				t.Errorf("onStart called %d times, want %d", started, test.started)
+			}
+			if test.waitErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.waitErr) {
+					t.Fatalf("Wait error = %v, want %q", err, test.waitErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Wait error: %s", err.Error())
+			}
+			if status.Succeeded != test.succeeded || status.Result != test.result || status.Duration != test.duration || status.Name != "run #7" {
+				t.Errorf("Wait = %+v, want %s in %s", status, test.result, test.duration)
+			}
+		})
+	}
+}
+
+func TestGithubRunLookupGivesUp(t *testing.T) {
+	fastDeployerPoll(t)
+	backend := newFakeBackend(map[string][]backendResponse{
+		"GET /repos/stevenfamy/grip/actions/workflows/release.yml/runs": {backendOk(`{"workflow_runs":[{"id":41,"display_title":"release 1.1.0"}]}`)},
+	})
+	server := httptest.NewServer(backend)
+	defer server.Close()
+
+	deployer := &githubDeployer{BaseURL: server.URL, Owner: "stevenfamy", Repo: "grip", Workflow: "release.yml", Ref: "main"}
+	deployment := &githubDeployment{deployer: deployer, dispatchId: "r-1", dispatchedAt: time.Now().Add(-githubRunLookup - time.Second)}
+	if _, _, err := waitDeployment(t, deployment, 5*time.Second); err == nil || !strings.Contains(err.Error(), "inputs.release_id") {
+		t.Errorf("Wait error = %v, want the run-name hint", err)
+	}
+}
+
+func TestGitlabDeployer(t *testing.T) {
+	fastDeployerPoll(t)
+	t.Setenv("DEPLOY_GRIP_TRIGGER", "trigger-token")
+	t.Setenv("DEPLOY_GRIP_API", "api-token")
+
+	const trigger = "POST /api/v4/projects/group/grip/trigger/pipeline"
+	const pipeline = "GET /api/v4/projects/group/grip/pipelines/9"
+	created := backendResponse{status: http.StatusCreated, body: `{"id":9,"status":"created","web_url":"https://gitlab.com/group/grip/-/pipelines/9"}`}
+	running := backendOk(`{"id":9,"status":"running","web_url":"https://gitlab.com/group/grip/-/pipelines/9"}`)
+
+	tests := []struct {
+		name      string
+		apiToken  bool
+		trigger   backendResponse
+		pipeline  []backendResponse
+		deployErr string
+		accepted  bool
+		succeeded bool
+		result    string
+		started   int
+		waitErr   string
+	}{
+		{
+			name:      "followed after transient errors",
+			apiToken:  true,
+			trigger:   created,
+			pipeline:  []backendResponse{backendOk(`{"id":9,"status":"pending"}`), running, {status: http.StatusServiceUnavailable}, backendOk(`{"id":9,"status":"success","duration":42}`)},
+			succeeded: true,
+			result:    "success",
+			started:   1,
+		},
+		{
+			name:     "failed pipeline",
+			apiToken: true,
+			trigger:  created,
+			pipeline: []backendResponse{running, backendOk(`{"id":9,"status":"failed","duration":3}`)},
+			result:   "failed",
+			started:  1,
+		},
+		{
+			name:     "bad api token is final",
+			apiToken: true,
+			trigger:  created,
+			pipeline: []backendResponse{{status: http.StatusUnauthorized}},
+			waitErr:  "401",
+		},
+		{
+			name:      "without api token the pipeline is accepted",
+			trigger:   created,
+			accepted:  true,
+			succeeded: true,
+			result:    "created",
+		},
+		{
+			name:      "trigger refused",
+			trigger:   backendResponse{status: http.StatusBadRequest},
+			deployErr: "gitlab pipeline trigger failed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backend := newFakeBackend(map[string][]backendResponse{trigger: {test.trigger}, pipeline: test.pipeline})
+			server := httptest.NewServer(backend)
+			defer server.Close()
+
+			config := fmt.Sprintf(`{"base_url":%q,"project_id":"group/grip","ref":"main","trigger_token_env":"DEPLOY_GRIP_TRIGGER"}`, server.URL)
+			if test.apiToken {
+				config = strings.Replace(config, "}", `,"api_token_env":"DEPLOY_GRIP_API"}`, 1)
+			}
+			deployer := &gitlabDeployer{}
+			if err := decodeDeployerConfig(DeployerGitlab, "grip", config, deployer); err != nil {
+				t.Fatalf("config error: %s", err.Error())
+			}
+			deployment, err := deployer.Deploy(context.Background(), DeployRequest{ReleaseId: "r-1", Project: "grip", Version: "1.2.0"})
+			if test.deployErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.deployErr) {
+					t.Fatalf("Deploy error = %v, want %q", err, test.deployErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Deploy error: %s", err.Error())
+			}
+			if sent := backend.sent(trigger)[0]; !strings.Contains(sent, "token=trigger-token") || !strings.Contains(sent, "RELEASE_VERSION%5D=1.2.0") {
+				t.Errorf("trigger form = %q", sent)
+			}
+
+			status, started, err := waitDeployment(t, deployment, 5*time.Second)
+			if started != test.started {
+				t.Errorf("onStart called %d times, want %d", started, test.started)
+			}
+			if test.waitErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.waitErr) {
+					t.Fatalf("Wait error = %v, want %q", err, test.waitErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Wait error: %s", err.Error())
+			}
+			if status.Accepted != test.accepted || status.Succeeded != test.succeeded || status.Result != test.result || status.Name != "pipeline #9" {
+				t.Errorf("Wait = %+v, want %s", status, test.result)
+			}
+			if test.apiToken && backend.first(pipeline).Header.Get("PRIVATE-TOKEN") != "api-token" {
+				t.Errorf("pipeline poll PRIVATE-TOKEN = %q", backend.first(pipeline).Header.Get("PRIVATE-TOKEN"))
+			}
+		})
+	}
+}
+
+func TestWebhookDeployer(t *testing.T) {
+	t.Setenv("DEPLOY_GRIP_CI_TOKEN", "ci-token")
+	t.Setenv("DEPLOY_OTHER_CI_TOKEN", "other-token")
+
+	tests := []struct {
+		name      string
+		config    string
+		status    int
+		configErr string
+		deployErr string
+	}{
+		{name: "accepted", config: `{"url":"{url}/deploy?v={{.Version | query}}","body":"{{.Project}} {{.ReleaseId}}","headers":{"Authorization":"Bearer {{env \"DEPLOY_GRIP_CI_TOKEN\"}}"}}`, status: http.StatusAccepted},
+		{name: "endpoint error", config: `{"url":"{url}/deploy"}`, status: http.StatusInternalServerError, deployErr: "500"},
+		{name: "bot secret", config: `{"url":"{url}/deploy","body":"{{env \"SLACK_AUTH_TOKEN\"}}"}`, configErr: "must start with DEPLOY_GRIP_"},
+		{name: "other project secret", config: `{"url":"{url}/deploy","body":"{{env \"DEPLOY_OTHER_CI_TOKEN\"}}"}`, configErr: "must start with DEPLOY_GRIP_"},
+		{name: "bad template", config: `{"url":"{url}/deploy","body":"{{.Nope}}"}`, configErr: "webhook body template"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backend := newFakeBackend(map[string][]backendResponse{"POST /deploy": {{status: test.status}}})
+			server := httptest.NewServer(backend)
+			defer server.Close()
+
+			deployer := &webhookDeployer{}
+			err := decodeDeployerConfig(DeployerWebhook, "grip", strings.ReplaceAll(test.config, "{url}", server.URL), deployer)
+			if test.configErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.configErr) {
+					t.Fatalf("config error = %v, want %q", err, test.configErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("config error: %s", err.Error())
+			}
+
+			deployment, err := deployer.Deploy(context.Background(), DeployRequest{ReleaseId: "r-1", Project: "grip", Version: "1.2.0 beta"})
+			if test.deployErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.deployErr) {
+					t.Fatalf("Deploy error = %v, want %q", err, test.deployErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Deploy error: %s", err.Error())
+			}
+
+			if got := backend.first("POST /deploy").Header.Get("Authorization"); got != "Bearer ci-token" {
+				t.Errorf("Authorization = %q", got)
+			}
+			if got := backend.first("POST /deploy").URL.RawQuery; got != "v=1.2.0+beta" {
+				t.Errorf("query = %q", got)
+			}
+			if got := backend.sent("POST /deploy")[0]; got != "grip r-1" {
+				t.Errorf("body = %q", got)
+			}
+			status, started, err := waitDeployment(t, deployment, time.Second)
+			if err != nil || !status.Accepted || !status.Succeeded || started != 0 {
+				t.Errorf("Wait = %+v, %v, onStart %d, want accepted right away", status, err, started)
+			}
+			if deployment.Description() != "POST "+server.URL {
+				t.Errorf("Description = %q, want no path nor query", deployment.Description())
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+)
+
+// webhookDeployer call any HTTP endpoint, URL, Body and Headers are text/template
+// executed with the DeployRequest, e.g. {"url": "https://ci.example.com/deploy?v={{.Version | query}}"}.
+// A credential is read from the bot environment with env, only the variables with the project prefix,
+// e.g. {"headers": {"Authorization": "Bearer {{env \"DEPLOY_LOGISTICS_CI_TOKEN\"}}"}}
+type webhookDeployer struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Body    string            `json:"body"`
+	Headers map[string]string `json:"headers"`
+
+	project string
+}
+
+func (d *webhookDeployer) funcs() template.FuncMap {
+	return template.FuncMap{
+		"query": url.QueryEscape,
+		"env": func(name string) (string, error) {
+			return deployerSecret(d.project, "env", name)
+		},
+	}
+}
+
+func (d *webhookDeployer) Name() string {
+	return DeployerWebhook
+}
+
+// validate render the templates once, so a template or env error show up when the config is saved
+func (d *webhookDeployer) validate(project string) error {
+	if d.URL == "" {
+		return errors.New("url is required")
+	}
+	d.project = project
+	_, err := d.render(DeployRequest{Project: project})
+	return err
+}
+
+func (d *webhookDeployer) render(release DeployRequest) (map[string]string, error) {
+	rendered := map[string]string{}
+	for name, text := range d.templates() {
+		tmpl, err := template.New(name).Funcs(d.funcs()).Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, release); err != nil {
+			return nil, fmt.Errorf("webhook %s template: %w", name, err)
+		}
+		rendered[name] = out.String()
+	}
+	return rendered, nil
+}
+
+func (d *webhookDeployer) templates() map[string]string {
+	templates := map[string]string{"url": d.URL, "body": d.Body}
+	for name, value := range d.Headers {
+		templates["header "+name] = value
+	}
+	return templates
+}
+
+func (d *webhookDeployer) Deploy(ctx context.Context, release DeployRequest) (Deployment, error) {
+	rendered, err := d.render(release)
+	if err != nil {
+		return nil, err
+	}
+
+	method := strings.ToUpper(d.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rendered["url"], strings.NewReader(rendered["body"]))
+	if err != nil {
+		return nil, err
+	}
+	for name := range d.Headers {
+		req.Header.Set(name, rendered["header "+name])
+	}
+
+	resp, err := deployerHTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling webhooks: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("webhook responded %s", resp.Status)
+	}
+
+	// a plain webhook cannot be followed, the release end once the endpoint accepted it
+	return &acceptedDeployment{
+		description: fmt.Sprintf("%s %s://%s", method, req.URL.Scheme, req.URL.Host),
+		status:      DeployStatus{Name: "webhook", Result: resp.Status},
+	}, nil
+}
@@ -7,5 +7,6 @@ require (
 	github.com/google/uuid v1.3.0 // indirect
 	github.com/gorilla/websocket v1.5.0 // indirect
 	github.com/joho/godotenv v1.4.0 // indirect
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/slack-go/slack v0.12.1 // indirect
 )
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/slack-go/slack"
+)
+
+// handleInteraction route the block actions of the bot messages to their handler, every click is audited
+func handleInteraction(callback slack.InteractionCallback, client *slack.Client) error {
+	switch callback.Type {
+	case slack.InteractionTypeShortcut:
+		if callback.CallbackID == releaseScheduleShortcut {
+			return openReleaseScheduleModal(client, callback.TriggerID, callback.User.ID, "", "")
+		}
+	case slack.InteractionTypeBlockActions:
+		for _, action := range callback.ActionCallback.BlockActions {
+			err := handleBlockAction(callback, action, client)
+			auditInteraction(callback, "action "+action.ActionID, actionValue(*action), err)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func handleBlockAction(callback slack.InteractionCallback, action *slack.BlockAction, client *slack.Client) error {
+	switch action.ActionID {
+	case actionReleaseConfirm, actionReleaseCancel:
+		return handleReleaseConfirmation(callback, action, client)
+	case actionHomeCancelSchedule:
+		return handleHomeCancelSchedule(callback, action, client)
+	case actionSandboxOfferAccept, actionSandboxOfferPass:
+		return handleSandboxOffer(callback, action, client)
+	case actionSandboxClaimExtend, actionSandboxClaimRelease:
+		return handleSandboxClaimReminder(callback, action, client)
+	case actionSandboxStatusProject:
+		return handleSandboxStatusPick(callback, action, client)
+	}
+	return nil
+}
+
+// handleViewSubmission answer a modal submit, the response is sent back with the acknowledge
+// so it must be returned quickly, nil close the modal
+func handleViewSubmission(callback slack.InteractionCallback, client *slack.Client) *slack.ViewSubmissionResponse {
+	var response *slack.ViewSubmissionResponse
+	switch callback.View.CallbackID {
+	case releaseScheduleModal:
+		response = handleReleaseScheduleSubmission(callback, client)
+	default:
+		return nil
+	}
+
+	var err error
+	if response != nil {
+		err = fmt.Errorf("%v", response.Errors)
+	}
+	auditInteraction(callback, "submit "+callback.View.CallbackID, viewValues(callback.View), err)
+	return response
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/stevenfamy/go-slackbot-release/config"
+)
+
+// JenkinsClient talk to the generic webhook trigger and the JSON API of one Jenkins host
+type JenkinsClient struct {
+	BaseURL string
+	// User and APIToken are used for the JSON API when Jenkins require authentication
+	User         string
+	APIToken     string
+	HTTP         *http.Client
+	PollInterval time.Duration
+}
+
+// JenkinsTrigger is the queued item created by the generic webhook trigger
+type JenkinsTrigger struct {
+	Job      string
+	QueueURL string
+}
+
+// JenkinsBuild is the state of a build from <build url>/api/json
+type JenkinsBuild struct {
+	Number   int
+	URL      string
+	Building bool
+	// Result is SUCCESS, UNSTABLE, FAILURE, ABORTED or NOT_BUILT once the build is done
+	Result   string
+	Duration time.Duration
+}
+
+// NewJenkinsClient create a client for host, host without scheme is http like the webhook always was
+func NewJenkinsClient(host string) *JenkinsClient {
+	baseURL := strings.TrimSuffix(host, "/")
+	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+		baseURL = "http://" + baseURL
+	}
+	return &JenkinsClient{
+		BaseURL:      baseURL,
+		User:         config.GetConfig("JENKINS_USER"),
+		APIToken:     config.GetConfig("JENKINS_API_TOKEN"),
+		HTTP:         &http.Client{Timeout: 30 * time.Second},
+		PollInterval: 10 * time.Second,
+	}
+}
+
+// TriggerWebhook call the generic webhook trigger and return the queued item of the triggered job
+func (j *JenkinsClient) TriggerWebhook(params url.Values) (*JenkinsTrigger, error) {
+	resp, err := j.HTTP.Get(j.BaseURL + "/generic-webhook-trigger/invoke?" + params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("error calling webhooks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("jenkins webhook responded %s", resp.Status)
+	}
+
+	// older plugin version return the jobs at the root, newer one wrap it in data
+	var body struct {
+		Jobs map[string]webhookJob `json:"jobs"`
+		Data struct {
+			Jobs map[string]webhookJob `json:"jobs"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("cannot read jenkins webhook response: %w", err)
+	}
+
+	jobs := body.Jobs
+	if len(jobs) == 0 {
+		jobs = body.Data.Jobs
+	}
+	for name, job := range jobs {
+		if job.Triggered && job.URL != "" {
+			return &JenkinsTrigger{Job: name, QueueURL: j.absolute(job.URL)}, nil
+		}
+	}
+
+	return nil, errors.New("jenkins did not trigger any job, check the project token")
+}
+
+type webhookJob struct {
+	Triggered bool   `json:"triggered"`
+	URL       string `json:"url"`
+}
+
+// WaitForBuild follow the queued item until the build finish, onStart is called once the build got a number.
+// A poll that fail for a transient reason, e.g. Jenkins restarting, is tried again until ctx expire
+func (j *JenkinsClient) WaitForBuild(ctx context.Context, trigger *JenkinsTrigger, onStart func(JenkinsBuild)) (JenkinsBuild, error) {
+	buildURL, err := j.waitForExecutable(ctx, trigger.QueueURL)
+	if err != nil {
+		return JenkinsBuild{}, err
+	}
+
+	build := JenkinsBuild{URL: buildURL}
+	started := false
+	err = pollDeployment(ctx, j.PollInterval, func() (bool, error) {
+		polled, err := j.getBuild(ctx, buildURL)
+		if err != nil {
+			return false, err
+		}
+		build = polled
+		if !started && onStart != nil {
+			onStart(build)
+			started = true
+		}
+		return !build.Building && build.Result != "", nil
+	}, func() string {
+		return fmt.Sprintf("build #%d did not finish in time", build.Number)
+	})
+	return build, err
+}
+
+func (j *JenkinsClient) waitForExecutable(ctx context.Context, queueURL string) (string, error) {
+	buildURL, why := "", ""
+	err := pollDeployment(ctx, j.PollInterval, func() (bool, error) {
+		var item struct {
+			Cancelled  bool   `json:"cancelled"`
+			Why        string `json:"why"`
+			Executable *struct {
+				Number int    `json:"number"`
+				URL    string `json:"url"`
+			} `json:"executable"`
+		}
+		if err := j.getJSON(ctx, queueURL+"api/json", &item); err != nil {
+			return false, err
+		}
+		if item.Cancelled {
+			return true, errors.New("jenkins cancelled the queued build")
+		}
+		why = item.Why
+		if item.Executable != nil && item.Executable.URL != "" {
+			buildURL = j.absolute(item.Executable.URL)
+			return true, nil
+		}
+		return false, nil
+	}, func() string {
+		return fmt.Sprintf("build did not leave the jenkins queue (%s)", why)
+	})
+	return buildURL, err
+}
+
+func (j *JenkinsClient) getBuild(ctx context.Context, buildURL string) (JenkinsBuild, error) {
+	var body struct {
+		Number   int    `json:"number"`
+		URL      string `json:"url"`
+		Building bool   `json:"building"`
+		Result   string `json:"result"`
+		Duration int64  `json:"duration"`
+	}
+	if err := j.getJSON(ctx, buildURL+"api/json", &body); err != nil {
+		return JenkinsBuild{URL: buildURL}, err
+	}
+
+	build := JenkinsBuild{
+		Number:   body.Number,
+		URL:      buildURL,
+		Building: body.Building,
+		Result:   body.Result,
+		Duration: time.Duration(body.Duration) * time.Millisecond,
+	}
+	if body.URL != "" {
+		build.URL = body.URL
+	}
+	return build, nil
+}
+
+func (j *JenkinsClient) getJSON(ctx context.Context, target string, value any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	if j.User != "" {
+		req.SetBasicAuth(j.User, j.APIToken)
+	}
+
+	resp, err := j.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling jenkins api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &statusError{Target: "jenkins api " + target, Status: resp.Status, StatusCode: resp.StatusCode}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(value); err != nil {
+		return fmt.Errorf("cannot read jenkins api %s response: %w", target, err)
+	}
+	return nil
+}
+
+// absolute resolve the relative url Jenkins return, e.g. queue/item/12/, against the base url
+func (j *JenkinsClient) absolute(target string) string {
+	if !strings.HasSuffix(target, "/") {
+		target += "/"
+	}
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		return target
+	}
+	return j.BaseURL + "/" + strings.TrimPrefix(target, "/")
+}
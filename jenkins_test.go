@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type jenkinsResponse struct {
+	status int
+	body   string
+}
+
+func jenkinsOk(body string) jenkinsResponse {
+	return jenkinsResponse{status: http.StatusOK, body: body}
+}
+
+// fakeJenkins answer the queue and build polls with the scripted responses in order, the last one is repeated
+type fakeJenkins struct {
+	mu    sync.Mutex
+	queue []jenkinsResponse
+	build []jenkinsResponse
+}
+
+func (f *fakeJenkins) next(responses *[]jenkinsResponse) jenkinsResponse {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	response := (*responses)[0]
+	if len(*responses) > 1 {
+		*responses = (*responses)[1:]
+	}
+	return response
+}
+
+func (f *fakeJenkins) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var response jenkinsResponse
+	switch r.URL.Path {
+	case "/generic-webhook-trigger/invoke":
+		if r.URL.Query().Get("token") != "sandbox-grip" {
+			response = jenkinsOk(`{"jobs":null,"message":"Did not find any jobs with token"}`)
+			break
+		}
+		response = jenkinsOk(`{"data":{"jobs":{"sandbox-grip":{"triggered":true,"url":"queue/item/12/"}}}}`)
+	case "/queue/item/12/api/json":
+		response = f.next(&f.queue)
+	case "/job/sandbox-grip/7/api/json":
+		response = f.next(&f.build)
+	default:
+		response = jenkinsResponse{status: http.StatusNotFound}
+	}
+
+	w.WriteHeader(response.status)
+	fmt.Fprint(w, strings.ReplaceAll(response.body, "{host}", "http://"+r.Host))
+}
+
+func TestJenkinsWaitForBuild(t *testing.T) {
+	queued := jenkinsOk(`{"why":"Waiting for next available executor"}`)
+	executable := jenkinsOk(`{"executable":{"number":7,"url":"{host}/job/sandbox-grip/7/"}}`)
+	building := jenkinsOk(`{"number":7,"url":"{host}/job/sandbox-grip/7/","building":true}`)
+	down := jenkinsResponse{status: http.StatusServiceUnavailable, body: "Jenkins is restarting"}
+
+	tests := []struct {
+		name     string
+		queue    []jenkinsResponse
+		build    []jenkinsResponse
+		timeout  time.Duration
+		result   string
+		duration time.Duration
+		started  int
+		err      string
+		timedOut bool
+	}{
+		{
+			name:     "success",
+			queue:    []jenkinsResponse{queued, executable},
+			build:    []jenkinsResponse{building, building, jenkinsOk(`{"number":7,"building":false,"result":"SUCCESS","duration":65000}`)},
+			result:   "SUCCESS",
+			duration: 65 * time.Second,
+			started:  1,
+		},
+		{
+			name:     "transient errors are retried",
+			queue:    []jenkinsResponse{queued, {status: http.StatusBadGateway}, executable},
+			build:    []jenkinsResponse{down, building, down, {status: http.StatusTooManyRequests}, jenkinsOk(`{"number":7,"result":"SUCCESS","duration":1000}`)},
+			result:   "SUCCESS",
+			duration: time.Second,
+			started:  1,
+		},
+		{
+			name:     "failed build",
+			queue:    []jenkinsResponse{executable},
+			build:    []jenkinsResponse{building, jenkinsOk(`{"number":7,"building":false,"result":"FAILURE","duration":3000}`)},
+			result:   "FAILURE",
+			duration: 3 * time.Second,
+			started:  1,
+		},
+		{
+			name:  "cancelled in the queue",
+			queue: []jenkinsResponse{queued, jenkinsOk(`{"cancelled":true}`)},
+			err:   "cancelled",
+		},
+		{
+			name:  "missing build is final",
+			queue: []jenkinsResponse{executable},
+			build: []jenkinsResponse{{status: http.StatusNotFound}},
+			err:   "404",
+		},
+		{
+			name:     "timeout while building",
+			queue:    []jenkinsResponse{executable},
+			build:    []jenkinsResponse{building},
+			timeout:  50 * time.Millisecond,
+			started:  1,
+			err:      "did not finish in time",
+			timedOut: true,
+		},
+		{
+			name:     "timeout while jenkins is down",
+			queue:    []jenkinsResponse{executable},
+			build:    []jenkinsResponse{building, down},
+			timeout:  50 * time.Millisecond,
+			started:  1,
+			err:      "last poll failed",
+			timedOut: true,
+		},
+		{
+			name:     "timeout in the queue",
+			queue:    []jenkinsResponse{queued},
+			timeout:  50 * time.Millisecond,
+			err:      "did not leave the jenkins queue",
+			timedOut: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := &fakeJenkins{queue: test.queue, build: test.build}
+			if fake.build == nil {
+				fake.build = []jenkinsResponse{{status: http.StatusNotFound}}
+			}
+			server := httptest.NewServer(fake)
+			defer server.Close()
+
+			jenkins := &JenkinsClient{BaseURL: server.URL, HTTP: server.Client(), PollInterval: time.Millisecond}
+			trigger, err := jenkins.TriggerWebhook(url.Values{"token": {"sandbox-grip"}})
+			if err != nil {
+				t.Fatalf("TriggerWebhook error: %s", err.Error())
+			}
+			if trigger.Job != "sandbox-grip" || trigger.QueueURL != server.URL+"/queue/item/12/" {
+				t.Fatalf("TriggerWebhook = %+v", trigger)
+			}
+
+			timeout := test.timeout
+			if timeout == 0 {
+				timeout = 5 * time.Second
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			started := 0
+			build, err := jenkins.WaitForBuild(ctx, trigger, func(build JenkinsBuild) {
+				started++
+				if build.Number != 7 || build.URL != server.URL+"/job/sandbox-grip/7/" {
+					t.Errorf("onStart build = %+v", build)
+				}
+			})
+
+			if started != test.started {
+				t.Errorf("onStart called %d times, want %d", started, test.started)
+			}
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("WaitForBuild error = %v, want %q", err, test.err)
+				}
+				if timedOut := errors.Is(err, context.DeadlineExceeded); timedOut != test.timedOut {
+					t.Errorf("WaitForBuild timed out = %v, want %v", timedOut, test.timedOut)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("WaitForBuild error: %s", err.Error())
+			}
+			if build.Result != test.result || build.Duration != test.duration || build.Building {
+				t.Errorf("WaitForBuild = %+v, want %s in %s", build, test.result, test.duration)
+			}
+		})
+	}
+}
+
+func TestJenkinsTriggerWithoutJob(t *testing.T) {
+	server := httptest.NewServer(&fakeJenkins{})
+	defer server.Close()
+
+	jenkins := &JenkinsClient{BaseURL: server.URL, HTTP: server.Client(), PollInterval: time.Millisecond}
+	if _, err := jenkins.TriggerWebhook(url.Values{"token": {"unknown"}}); err == nil {
+		t.Error("TriggerWebhook with an unknown token, want an error")
+	}
+}
@@ -6,11 +6,7 @@ import (
 	"fmt"
 	"html"
 	"log"
-	"net/http"
 	"os"
-	"regexp"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/slack-go/slack"
@@ -96,13 +92,40 @@ func main() {
 					// handleSlashCommand will take care of the command
 					err := handleSlashCommand(command, client)
 					if err != nil {
-						log.Fatal(err)
+						// a failed reply or modal should not stop the bot
+						log.Println(err.Error())
+					}
+				case socketmode.EventTypeInteractive:
+					// button clicks on the bot messages
+					callback, ok := event.Data.(slack.InteractionCallback)
+					if !ok {
+						log.Printf("Could not type cast the event to the InteractionCallback: %v\n", event)
+						continue
+					}
+					if callback.Type == slack.InteractionTypeViewSubmission {
+						// modal errors are returned in the acknowledge payload
+						if response := handleViewSubmission(callback, client); response != nil {
+							socket.Ack(*event.Request, response)
+						} else {
+							socket.Ack(*event.Request)
+						}
+						continue
+					}
+					socket.Ack(*event.Request)
+					if err := handleInteraction(callback, client); err != nil {
+						log.Println(err.Error())
 					}
 				}
 			}
 		}
 	}(ctx, client, socket)
 
+	//inbound webhooks, e.g. Jenkins reporting the sandbox builds
+	startWebhookServer(client)
+
+	//the releases a previous run was following are not followed by anyone anymore
+	failInterruptedReleases(client)
+
 	//thread of looping ticker to check every minutes
 	go func() {
 		for {
@@ -112,41 +135,26 @@ func main() {
 			// interval task
 			case tm := <-ticker.C:
 
-				//get time now in +8
-				location, _ := time.LoadLocation("Asia/Singapore")
-				now := tm.In(location)
+				//turn due recurrence into schedule first so they are released in the same tick
+				runDueRecurrence(tm)
 
-				///get schedule from db
-				results, err := models.DB.Query("SELECT * FROM release_schedule WHERE released = 0")
+				//release_on is an absolute timestamp so only the due schedule is returned
+				runDueRelease(client, tm)
 
-				if err != nil {
-					log.Print(err.Error())
-				}
+				//close the release confirmation nobody answered
+				expireReleaseConfirmations(client, tm)
 
-				//loop all active schedule
-				for results.Next() {
-					var releaseSchedule models.ReleaseSchedule
+				//pass the sandbox server offers nobody accepted to the next in the waitlist
+				expireSandboxOffers(client, tm)
 
-					//map to struct
-					err = results.Scan(&releaseSchedule.Id, &releaseSchedule.ReleaseOn, &releaseSchedule.ReleaseProject, &releaseSchedule.ReleaseVersion, &releaseSchedule.Released, &releaseSchedule.CreatedAt, &releaseSchedule.CreatedBy)
+				//remind and free the sandbox claims about to expire
+				runSandboxClaims(client, tm)
 
-					if err != nil {
-						log.Print(err.Error())
-					}
+				//probe the sandbox health urls, it only run once per minute
+				probeSandboxServers(tm)
 
-					//parse and check time
-					converted, _ := time.Parse(time.Kitchen, releaseSchedule.ReleaseOn)
-					t1 := time.Date(now.Year(), now.Month(), now.Day(), converted.Hour(), converted.Minute(), 0, 0, now.Location())
-					if now.After(t1) {
-						//time ok
-						log.Println("OK Release", releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion)
-						//update to 1
-						callJenkins(releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion, false, "0000")
-						models.UpdateReleased(releaseSchedule.Id)
-					} else {
-						log.Println(releaseSchedule.Id, "Time not match yet")
-					}
-				}
+				//grant and revoke the roles given to the members of the bound user groups
+				runAccessGroupSync(client, tm)
 			}
 		}
 	}()
@@ -169,6 +177,13 @@ func HandleEventMessage(event slackevents.EventsAPIEvent, client *slack.Client)
 			if err != nil {
 				return err
 			}
+		case *slackevents.AppHomeOpenedEvent:
+			// the Home tab is rendered on every open so it is never stale
+			if ev.Tab == "home" {
+				if err := publishAppHome(client, ev.User); err != nil {
+					log.Println(err.Error())
+				}
+			}
 		}
 	default:
 		return errors.New("unsupported event type")
@@ -184,355 +199,45 @@ func HandleAppMentionEventToBot(event *slackevents.AppMentionEvent, client *slac
 	if err != nil {
 		return err
 	}
-	// Check if the user said Hello to the bot
-	text := strings.ToLower(html.UnescapeString(event.Text))
+	// Keep the original case, command keywords are matched case insensitive but
+	// arguments like version, token and slack id must be passed as typed
+	text := stripMention(html.UnescapeString(normalizeText(event.Text)))
 	log.Println(text)
-	// Create the attachment and assigned based on the message
-	attachment := slack.Attachment{}
-
-	// projectList := []string{"gla-platform", "gla-parent", "gla-admin", "logistics-backend", "logistics-web", "logistics-mobile"}
-
-	if strings.Contains(text, "my id") {
-		// Send a message to the user
-		attachment.Text = fmt.Sprintf("Psst <@%s> your slack id is %s", user.ID, user.ID)
-		// attachment.Pretext = "How can I be of service"
-		attachment.Color = "#563a9b"
-	} else if strings.Contains(text, "how to schedule release") {
-		// Send a message to the user
-		attachment.Text = fmt.Sprintf("Easy <@%s>, you just need to mention me with this message format 'schedule release projectname <<version>> at hh:mma' in Asia/Singapore timezone", user.ID)
-		// attachment.Pretext = "How can I be of service"
-		attachment.Footer = "Example: schedule release logistics-backend <<backend-1.1.0-beta>> at 09:25PM"
-		attachment.Color = "#563a9b"
-	} else if strings.Contains(text, "how to remove schedule") {
-		// Send a message to the user
-		attachment.Text = fmt.Sprintf("Easy <@%s>, you just need to mention me with this message format 'remove schedule id'", user.ID)
-		// attachment.Pretext = "How can I be of service"
-		attachment.Footer = "Example: remove schedule 7ede5801-f6bb-4eaf-926f-54ee7f65905c "
-		attachment.Color = "#563a9b"
-	} else if strings.Contains(text, "how to release") {
-		// Send a message to the user
-		attachment.Text = fmt.Sprintf("Ok <@%s>, you just need to mention me with this message format 'release projectname <<version>>'", user.ID)
-		// attachment.Pretext = "How can I be of service"
-		attachment.Footer = "Example: release logistics-backend <<backend-1.1.0-beta>>"
-		attachment.Color = "#563a9b"
-	} else if strings.Contains(text, "help") {
-		// Send a message to the user
-		attachment.Text = fmt.Sprintf("Howdy <@%s> :mixue:, this is the availble command list\n 1. how to schedule release \n 2. how to remove schedule \n 3. how to release \n 4. project list \n 5. who are you \n 6. schedule release ... \n 7. release ... \n 8. active schedule \n 9. remove schedule \n 10. access list \n 11. add access \n 12. delete access \n 13. enable access \n 14. disable access \n 15. test access \n 16. project list \n 17. add project \n 18. delete project \n 19. enable project \n 20. disable project \n 21. test project \n 22. my id", user.ID)
-		// attachment.Pretext = "How can I be of service"
-		attachment.Footer = "GRIP Release Bot."
-		attachment.Color = "#563a9b"
-	} else if strings.Contains(text, "who are you") {
-		// Send a message to the user
-		attachment.Text = fmt.Sprintf("Yo <@%s>, I'm ~Snow King~ I mean Bot that handle release or deploy a project to server and living in GRIP Principle Slack 😁😁", user.ID)
-		attachment.Footer = "Build using Go."
-		attachment.Color = "#563a9b"
-	} else if strings.Contains(text, "env") {
-		// Send a message to the user
-		attachment.Text = fmt.Sprintf("Bibop <@%s>, current env is set to %s", user.ID, config.GetConfig("ENVIRONMENT"))
-		attachment.Footer = "Build using Go."
-		attachment.Color = "#563a9b"
-	} else if strings.Contains(text, "active schedule") {
-		// Send a message to the user
-		result := models.GetActiveRelease()
-		if result == "" {
-			attachment.Text = fmt.Sprintf("Woah <@%s>, currently no active schedule", user.ID)
-		} else {
-			attachment.Text = fmt.Sprintf("Wow <@%s>, this is the active schedule: \n\n %s", user.ID, result)
-		}
-		attachment.Footer = "Build using Go."
-		attachment.Color = "#563a9b"
-	} else if strings.Contains(text, "remove schedule") {
-		if models.UserHasAccess((user.ID)) {
-
-			re := regexp.MustCompile(`remove schedule ([^}]*).*`)
-			match := re.FindStringSubmatch(text)
-			fmt.Println(text)
-			if match != nil {
-				result := models.CheckActiveRelease(match[1])
-				if !result {
-					attachment.Text = fmt.Sprintf("Hmm <@%s>, no active schedule with this Id", user.ID)
-				} else {
-					models.UpdateReleased(match[1])
-					attachment.Text = fmt.Sprintf("Noted <@%s>, this schedule is removed :noted:", user.ID)
-				}
-				attachment.Footer = "Build using Go."
-				attachment.Color = "#4af030"
-
-			} else {
-				attachment.Text = fmt.Sprintf("Sorry <@%s>, You need to supply the active schedule id", user.ID)
-				attachment.Color = "#e20228"
-				attachment.Footer = fmt.Sprintf("GRIP Release Bot cannot continue, '%s'", text)
-			}
-		}
-	} else if strings.Contains(text, "schedule release") {
-		if models.UserHasAccess((user.ID)) {
-			fmt.Println("schedule release is executed", text)
-			re := regexp.MustCompile(`schedule release ([^}]*) \<<([^}]*)\>> at ([^}]*).*`)
-			match := re.FindStringSubmatch(text)
-
-			if match != nil {
-				timeInput := strings.ToUpper(match[3])
-				timeRegex := regexp.MustCompile(`^(0?[1-9]|1[012]):([0-5][0-9])[AP]M$`)
-				timeMatch := timeRegex.FindStringSubmatch(timeInput)
-
-				if timeMatch != nil {
-					if models.ProjectIsAvailable((match[1])) {
-						attachment.Text = fmt.Sprintf("Roger <@%s>, Create release schedule for %s version %s at %s", user.ID, match[1], match[2], timeInput)
-						attachment.Color = "#4af030"
-						attachment.Footer = "GRIP Release Bot create release schedule."
-
-						models.CreateSchedule(match[1], match[2], timeInput, user.Name)
-					} else {
-						attachment.Text = fmt.Sprintf("Sorry <@%s>, the project %s is not found, use command project list to see the supported project", user.ID, match[1])
-						attachment.Color = "#e20228"
-						attachment.Footer = fmt.Sprintf("GRIP Release Bot cannot continue, '%s'", text)
-					}
-				} else {
-					attachment.Text = fmt.Sprintf("Sorry <@%s>, looks like your time format is wrong, should be hh:mma in Asia/Singapore timezone, e.g 09:00PM", user.ID)
-					attachment.Color = "#e20228"
-					attachment.Footer = fmt.Sprintf("GRIP Release Bot cannot continue, '%s'", text)
-				}
-
-			} else {
-				attachment.Text = fmt.Sprintf("Sorry <@%s>, you need to tell me the project name, version, and the release time, or make sure the message format is correct, use command 'how to schedule release' to see the format 😉", user.ID)
-				attachment.Color = "#e20228"
-				attachment.Footer = fmt.Sprintf("GRIP Release Bot cannot continue, '%s'", text)
-			}
-		} else {
-			attachment.Text = "Sorry you don't have permission 🙏"
-			attachment.Color = "#e20228"
-			attachment.Footer = "GRIP Release Bot cannot continue"
-		}
-	} else if strings.Contains(text, "release") {
-		if models.UserHasAccess((user.ID)) {
-
-			re := regexp.MustCompile(`release ([^}]*) \<<([^}]*)\>>.*`)
-			match := re.FindStringSubmatch(text)
-
-			if match != nil {
-				if models.ProjectIsAvailable((match[1])) {
-					attachment.Text = fmt.Sprintf("Affirmative <@%s>, Releasing %s version %s now.", user.ID, match[1], match[2])
-					attachment.Color = "#4af030"
-					attachment.Footer = "GRIP Release Bot calling Jenkins..."
-
-					callJenkins(match[1], match[2], false, "0000")
-					log.Println(match[1], match[2])
-				} else {
-					attachment.Text = fmt.Sprintf("Sorry <@%s>, the project %s is not found, use command project list to see the supported project", user.ID, match[1])
-					attachment.Color = "#e20228"
-					attachment.Footer = fmt.Sprintf("GRIP Release Bot cannot continue, '%s'", text)
-				}
 
-			} else {
-				attachment.Text = fmt.Sprintf("Sorry <@%s>, you need to tell me the project name & version, or make sure the message format is correct, use command 'how to release' to see the format 😉", user.ID)
-				attachment.Color = "#e20228"
-				attachment.Footer = fmt.Sprintf("GRIP Release Bot cannot continue, '%s'", text)
-			}
-		} else {
-			attachment.Text = "Sorry you don't have permission 🙏"
-			attachment.Color = "#e20228"
-			attachment.Footer = "GRIP Release Bot cannot continue"
-		}
-	} else if strings.Contains(text, "access list") {
-		if models.UserIsAdmin((user.ID)) {
-			result := models.GetAllUsers()
-			if result != "" {
-				attachment.Text = fmt.Sprintf("Gotcha <@%s>, this is the access list: \n\n %s", user.ID, result)
-			} else {
-				attachment.Text = fmt.Sprintf("Sorry <@%s>, access list is empty.", user.ID)
-			}
-		} else {
-			attachment.Text = fmt.Sprintf("Sorry <@%s>, you don't have the permission to do that", user.ID)
-		}
-	} else if strings.Contains(text, "add access") {
-		if models.UserIsAdmin((user.ID)) {
-			re := regexp.MustCompile(`add access ([^}]*)\-([^}]*).*`)
-			match := re.FindStringSubmatch(text)
-			if match != nil {
-				attachment.Text = fmt.Sprintf("Roger <@%s>, Adding access for %s.", user.ID, match[2])
-
-				models.AddNewUser(match[1], match[2])
-			} else {
-				attachment.Text = fmt.Sprintf("Sorry <@%s>, make sure the format is 'add access SLACKID-name'.", user.ID)
-			}
-		} else {
-			attachment.Text = fmt.Sprintf("Sorry <@%s>, you don't have the permission to do that", user.ID)
-		}
-	} else if strings.Contains(text, "delete access") {
-		if models.UserIsAdmin((user.ID)) {
-			re := regexp.MustCompile(`delete access ([^}]*).*`)
-			match := re.FindStringSubmatch(text)
-			if match != nil {
-				attachment.Text = fmt.Sprintf("Roger <@%s>, Removing access for %s.", user.ID, strings.ToUpper(match[1]))
-
-				models.DeleteUserAccess(match[1])
-			} else {
-				attachment.Text = fmt.Sprintf("Sorry <@%s>, make sure the format is 'delete access SLACKID'.", user.ID)
-			}
-		} else {
-			attachment.Text = fmt.Sprintf("Sorry <@%s>, you don't have the permission to do that", user.ID)
-		}
-	} else if strings.Contains(text, "enable access") {
-		if models.UserIsAdmin((user.ID)) {
-			re := regexp.MustCompile(`enable access ([^}]*).*`)
-			match := re.FindStringSubmatch(text)
-			if match != nil {
-				attachment.Text = fmt.Sprintf("Roger <@%s>, Enabling access for %s.", user.ID, strings.ToUpper(match[1]))
-
-				models.ToogleUserStatus(match[1], true)
-			} else {
-				attachment.Text = fmt.Sprintf("Sorry <@%s>, make sure the format is 'enable access SLACKID'.", user.ID)
-			}
-		} else {
-			attachment.Text = fmt.Sprintf("Sorry <@%s>, you don't have the permission to do that", user.ID)
-		}
-	} else if strings.Contains(text, "disable access") {
-		if models.UserIsAdmin((user.ID)) {
-			re := regexp.MustCompile(`disable access ([^}]*).*`)
-			match := re.FindStringSubmatch(text)
-			if match != nil {
-				attachment.Text = fmt.Sprintf("Roger <@%s>, Disabling access for %s.", user.ID, strings.ToUpper(match[1]))
-
-				models.ToogleUserStatus(match[1], false)
-			} else {
-				attachment.Text = fmt.Sprintf("Sorry <@%s>, make sure the format is 'disable access SLACKID'.", user.ID)
-			}
-		} else {
-			attachment.Text = fmt.Sprintf("Sorry <@%s>, you don't have the permission to do that", user.ID)
-		}
-	} else if strings.Contains(text, "test access") {
-		if models.UserHasAccess((user.ID)) {
-			attachment.Text = fmt.Sprintf("Congrats <@%s>, you have the access", user.ID)
+	// reply in the thread the bot was mentioned in, or start one on the mention
+	threadTs := event.ThreadTimeStamp
+	if threadTs == "" {
+		threadTs = event.TimeStamp
+	}
 
-		} else {
-			attachment.Text = fmt.Sprintf("Sorry <@%s>, you don't have the permission to do that", user.ID)
-		}
-	} else if strings.Contains(text, "project list") {
-		if models.UserIsAdmin((user.ID)) {
-			result := models.GetAllProjects()
-			if result != "" {
-				attachment.Text = fmt.Sprintf("Gotcha <@%s>, this is the project list: \n\n %s", user.ID, result)
-			} else {
-				attachment.Text = fmt.Sprintf("Sorry <@%s>, project list is empty.", user.ID)
-			}
-		} else {
-			attachment.Text = fmt.Sprintf("Sorry <@%s>, you don't have the permission to do that", user.ID)
-		}
-	} else if strings.Contains(text, "add project") {
-		if models.UserIsAdmin((user.ID)) {
-			re := regexp.MustCompile(`add project ([^}]*)\|([^}]*)\|([^}]*).*`)
-			match := re.FindStringSubmatch(text)
-			if match != nil {
-				attachment.Text = fmt.Sprintf("Roger <@%s>, Adding project %s.", user.ID, match[1])
-
-				models.AddNewProject(match[1], match[2], match[3])
-			} else {
-				attachment.Text = fmt.Sprintf("Sorry <@%s>, make sure the format is 'add project project name|jenkins token|jenkins host'.", user.ID)
-			}
-		} else {
-			attachment.Text = fmt.Sprintf("Sorry <@%s>, you don't have the permission to do that", user.ID)
-		}
-	} else if strings.Contains(text, "delete project") {
-		if models.UserIsAdmin((user.ID)) {
-			re := regexp.MustCompile(`delete project ([^}]*).*`)
-			match := re.FindStringSubmatch(text)
-			if match != nil {
-				attachment.Text = fmt.Sprintf("Roger <@%s>, Removing project %s.", user.ID, strings.ToUpper(match[1]))
-
-				models.DeleteProject(match[1])
-			} else {
-				attachment.Text = fmt.Sprintf("Sorry <@%s>, make sure the format is 'delete project project-name'.", user.ID)
-			}
-		} else {
-			attachment.Text = fmt.Sprintf("Sorry <@%s>, you don't have the permission to do that", user.ID)
-		}
-	} else if strings.Contains(text, "enable project") {
-		if models.UserIsAdmin((user.ID)) {
-			re := regexp.MustCompile(`enable project ([^}]*).*`)
-			match := re.FindStringSubmatch(text)
-			if match != nil {
-				attachment.Text = fmt.Sprintf("Roger <@%s>, Enabling project %s.", user.ID, strings.ToUpper(match[1]))
-
-				models.ToogleProject(match[1], true)
-			} else {
-				attachment.Text = fmt.Sprintf("Sorry <@%s>, make sure the format is 'enable project project-name'.", user.ID)
-			}
-		} else {
-			attachment.Text = fmt.Sprintf("Sorry <@%s>, you don't have the permission to do that", user.ID)
-		}
-	} else if strings.Contains(text, "disable project") {
-		if models.UserIsAdmin((user.ID)) {
-			re := regexp.MustCompile(`disable project ([^}]*).*`)
-			match := re.FindStringSubmatch(text)
-			if match != nil {
-				attachment.Text = fmt.Sprintf("Roger <@%s>, Disabling project %s.", user.ID, strings.ToUpper(match[1]))
-
-				models.ToogleProject(match[1], false)
-			} else {
-				attachment.Text = fmt.Sprintf("Sorry <@%s>, make sure the format is 'disable project project-name'.", user.ID)
-			}
-		} else {
-			attachment.Text = fmt.Sprintf("Sorry <@%s>, you don't have the permission to do that", user.ID)
-		}
-	} else if strings.Contains(text, "test project") {
-		if models.UserIsAdmin((user.ID)) {
-			re := regexp.MustCompile(`test project ([^}]*).*`)
-			match := re.FindStringSubmatch(text)
-			if match != nil {
-				result := models.GetProjectToken(match[1])
-
-				attachment.Text = fmt.Sprintf("Roger <@%s>, test project %s, the token is %s", user.ID, strings.ToUpper(match[1]), result)
-			} else {
-				attachment.Text = fmt.Sprintf("Sorry <@%s>, make sure the format is 'test project project-name'.", user.ID)
-			}
-		} else {
-			attachment.Text = fmt.Sprintf("Sorry <@%s>, you don't have the permission to do that", user.ID)
-		}
-		//TESTING SERVER COMMAND
-	} else if strings.Contains(text, "sandbox server status") {
-		// if models.UserHasAccessServerStatus((user.ID)) {
-		re := regexp.MustCompile(`sandbox server status ([^}]*).*`)
-		match := re.FindStringSubmatch(text)
-		if match != nil {
-			result := models.GetServerStatus(match[1])
-			if result != "" {
-				attachment.Text = fmt.Sprintf("Gotcha <@%s>, this is the Sandbox Server Status for project %s: \n\n %s", user.ID, match[1], result)
-			} else {
-				attachment.Text = fmt.Sprintf("Sorry <@%s>, Sandbox Server Status not found.", user.ID)
-			}
-		} else {
-			attachment.Text = fmt.Sprintf("Sorry <@%s>, make sure the format is 'sandbox server status project-name'.", user.ID)
-		}
-		// } else {
-		// 	attachment.Text = fmt.Sprintf("Sorry <@%s>, you don't have the permission to do that", user.ID)
-		// }
-	} else if strings.Contains(text, "done sandbox server") {
-		// if models.UserHasAccessServerStatus((user.ID)) {
-		re := regexp.MustCompile(`done sandbox server ([^}]*),([^}]*)`)
-		match := re.FindStringSubmatch(text)
-		if match != nil {
-			var Name = models.GetUserName(user.ID)
-			models.UpdateServerStatus(match[1], match[2], Name)
-
-			attachment.Text = fmt.Sprintf("On it <@%s>, Set Sandbox Server Status project %s and server %s to Not in use", user.ID, match[1], match[2])
-		} else {
-			attachment.Text = fmt.Sprintf("Sorry <@%s>, make sure the format is 'done sandbox server  project-name,server-id'.", user.ID)
-		}
-		// } else {
-		// 	attachment.Text = fmt.Sprintf("Sorry <@%s>, you don't have the permission to do that", user.ID)
-		// }
-	} else {
+	// Find the command in the registry, it also take care of permission and argument format
+	attachment, ok := botCommands.Dispatch(&CommandRequest{
+		Client:   client,
+		User:     user,
+		Channel:  event.Channel,
+		ThreadTs: threadTs,
+		Text:     text,
+		Post: func(options ...slack.MsgOption) (string, error) {
+			_, ts, err := client.PostMessage(event.Channel, options...)
+			return ts, err
+		},
+	})
+	if !ok {
 		if user.ID == "U023A0BJUB1" {
 			attachment.Text = ":ice_cube: :tea:"
 			attachment.Color = "#FF00FF"
 		} else {
 			// Send a message to the user
 			attachment.Text = fmt.Sprintf("Hi <@%s>", user.ID)
-			// attachment.Pretext = "How can I be of service"
 			attachment.Color = "#563a9b"
 		}
 	}
 
+	// the handler already replied
+	if attachment.Text == "" {
+		return nil
+	}
+
 	// Send the message to the channel
 	// The Channel is available in the event message
 	_, _, err = client.PostMessage(event.Channel, slack.MsgOptionAttachments(attachment))
@@ -548,57 +253,21 @@ func handleSlashCommand(command slack.SlashCommand, client *slack.Client) error
 	case "/sandbox_server_status_logistics":
 		// This was a hello command, so pass it along to the proper function
 		return handleStatusCommand(command, client)
+	case "/sandbox":
+		return handleSandboxCommand(command, client)
+	case "/release":
+		return handleReleaseCommand(command, client)
+	case "/release-schedule":
+		return handleReleaseScheduleCommand(command, client)
 	}
 
 	return nil
 }
 
+// handleStatusCommand keep the legacy logistics command working, it always answered in the channel
 func handleStatusCommand(command slack.SlashCommand, client *slack.Client) error {
-	// The Input is found in the text field so
-	// Create the attachment and assigned based on the message
-	attachment := slack.Attachment{}
-	// Add Some default context like user who mentioned the bot
-	attachment.Fields = []slack.AttachmentField{}
-
-	result := models.GetServerStatus("logistics")
-
-	if result != "" {
-		attachment.Text = fmt.Sprintf("Gotcha <@%s>, this is the Sandbox Server Status for project %s: \n\n %s", command.UserName, "logistics", result)
-	} else {
-		attachment.Text = fmt.Sprintf("Sorry <@%s>, Sandbox Server Status not found.", command.UserName)
-	}
-
-	// Send the message to the channel
-	// The Channel is available in the command.ChannelID
-	_, _, err := client.PostMessage(command.ChannelID, slack.MsgOptionAttachments(attachment))
-	if err != nil {
-		return fmt.Errorf("failed to post message: %w", err)
-	}
-	return nil
-}
-
-// self-explanatory
-func callJenkins(project string, version string, isSchedule bool, time string) {
-	env := config.GetConfig("ENVIRONMENT")
-	isTesting := true
-	jenkinsAddress := models.GetProjectJenkinsHost(project)
-	jenkinsToken := models.GetProjectToken(project)
-	log.Print("jenkinsAddress", jenkinsAddress)
-	log.Print("jenkinsToken", jenkinsToken)
-
-	if env == "production" {
-		isTesting = false
-	}
-	log.Print("isTesting", isTesting)
-
-	jenkinsWebhook := "http://" + jenkinsAddress + "/generic-webhook-trigger/invoke?token=" + jenkinsToken + "&buildEnv=production&release_version=" + version + "&project_id=null&release_id=null&release_timer=" + strconv.FormatBool(isSchedule) + "&release_at=" + time + "&test_release=" + strconv.FormatBool(isTesting)
-
-	// fmt.Println(jenkinsWebhook)
-	_, err := http.Get(jenkinsWebhook)
-
-	if err != nil {
-		log.Println("error calling webhooks: " + err.Error())
-	}
+	command.Text = "status logistics " + sandboxPublicFlag
+	return handleSandboxCommand(command, client)
 }
 
 // func contains(s []string, str string) bool {
@@ -0,0 +1,14 @@
+-- release_on used to be a time.Kitchen string (e.g. 09:00PM) without a date,
+-- it now hold the unix timestamp of when the release should run.
+-- Legacy rows are converted using the day they were created in Asia/Singapore.
+SET time_zone = '+08:00';
+
+ALTER TABLE release_schedule ADD COLUMN release_on_ts BIGINT NOT NULL DEFAULT 0 AFTER release_on;
+
+UPDATE release_schedule
+SET release_on_ts = UNIX_TIMESTAMP(CONCAT(DATE(FROM_UNIXTIME(created_at)), ' ', TIME(STR_TO_DATE(release_on, '%h:%i%p'))))
+WHERE STR_TO_DATE(release_on, '%h:%i%p') IS NOT NULL;
+
+ALTER TABLE release_schedule DROP COLUMN release_on;
+ALTER TABLE release_schedule CHANGE release_on_ts release_on BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE release_schedule ADD INDEX idx_release_schedule_due (released, release_on);
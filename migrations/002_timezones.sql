@@ -0,0 +1,9 @@
+-- Per user timezone preference, when missing the Slack profile timezone is used.
+CREATE TABLE IF NOT EXISTS user_preference (
+  slack_id VARCHAR(32) NOT NULL PRIMARY KEY,
+  timezone VARCHAR(64) NOT NULL,
+  updated_at BIGINT NOT NULL
+);
+
+-- Per project default timezone, empty means the bot DEFAULT_TIMEZONE.
+ALTER TABLE projects ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT '';
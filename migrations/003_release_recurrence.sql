@@ -0,0 +1,16 @@
+-- Recurring release, every run create a release_schedule row linked by recurrence_id.
+CREATE TABLE IF NOT EXISTS release_recurrence (
+  id VARCHAR(36) NOT NULL PRIMARY KEY,
+  cron_expr VARCHAR(128) NOT NULL,
+  timezone VARCHAR(64) NOT NULL,
+  release_project VARCHAR(255) NOT NULL,
+  release_version VARCHAR(255) NOT NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'active',
+  last_run_on BIGINT NOT NULL DEFAULT 0,
+  next_run_on BIGINT NOT NULL DEFAULT 0,
+  created_at BIGINT NOT NULL,
+  created_by VARCHAR(255) NOT NULL,
+  INDEX idx_release_recurrence_due (status, next_run_on)
+);
+
+ALTER TABLE release_schedule ADD COLUMN recurrence_id VARCHAR(36) NOT NULL DEFAULT '';
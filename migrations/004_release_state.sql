@@ -0,0 +1,23 @@
+-- Replace the released 0/1 flag with explicit lifecycle states.
+-- Fired and removed schedule were both released = 1, they are migrated as triggered
+-- since the old data cannot tell them apart.
+ALTER TABLE release_schedule
+  ADD COLUMN state VARCHAR(16) NOT NULL DEFAULT 'pending' AFTER release_version,
+  ADD COLUMN state_changed_at BIGINT NOT NULL DEFAULT 0 AFTER state;
+
+UPDATE release_schedule SET state = IF(released = 1, 'triggered', 'pending'), state_changed_at = created_at;
+
+ALTER TABLE release_schedule DROP INDEX idx_release_schedule_due;
+ALTER TABLE release_schedule DROP COLUMN released;
+ALTER TABLE release_schedule ADD INDEX idx_release_schedule_due (state, release_on);
+
+CREATE TABLE IF NOT EXISTS release_transition (
+  id VARCHAR(36) NOT NULL PRIMARY KEY,
+  schedule_id VARCHAR(36) NOT NULL,
+  from_state VARCHAR(16) NOT NULL,
+  to_state VARCHAR(16) NOT NULL,
+  actor VARCHAR(255) NOT NULL,
+  note VARCHAR(1024) NOT NULL DEFAULT '',
+  created_at BIGINT NOT NULL,
+  INDEX idx_release_transition_schedule (schedule_id, created_at)
+);
@@ -0,0 +1,6 @@
+-- Where a release was requested, build progress is posted back to that thread.
+ALTER TABLE release_schedule
+  ADD COLUMN channel VARCHAR(32) NOT NULL DEFAULT '',
+  ADD COLUMN thread_ts VARCHAR(32) NOT NULL DEFAULT '';
+
+ALTER TABLE release_recurrence ADD COLUMN channel VARCHAR(32) NOT NULL DEFAULT '';
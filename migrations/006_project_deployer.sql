@@ -0,0 +1,6 @@
+-- Backend used to release a project, jenkins keep using jenkins_host and jenkins_token,
+-- gitlab, github and webhook read their JSON config from deployer_config.
+-- The config only name the bot environment variables holding the tokens, never the tokens themselves.
+ALTER TABLE projects
+  ADD COLUMN deployer VARCHAR(16) NOT NULL DEFAULT 'jenkins',
+  ADD COLUMN deployer_config TEXT NULL;
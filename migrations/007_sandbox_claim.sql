@@ -0,0 +1,6 @@
+-- Sandbox server claim, status = 1 with claimed_by_id set is a claim made from the bot,
+-- status_changed_by keep the display name of whoever changed the status last.
+ALTER TABLE testing_status
+  ADD COLUMN claimed_by_id VARCHAR(32) NOT NULL DEFAULT '',
+  ADD COLUMN claim_reason VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN claim_until BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,16 @@
+-- FIFO waitlist per sandbox project, a freed server is offered to the oldest waiting entry
+-- which is reserved for it until offer_expires_at.
+CREATE TABLE IF NOT EXISTS sandbox_waitlist (
+  id VARCHAR(36) NOT NULL PRIMARY KEY,
+  project VARCHAR(255) NOT NULL,
+  slack_id VARCHAR(32) NOT NULL,
+  user_name VARCHAR(255) NOT NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'waiting',
+  server_id VARCHAR(64) NOT NULL DEFAULT '',
+  offer_expires_at BIGINT NOT NULL DEFAULT 0,
+  offer_channel VARCHAR(32) NOT NULL DEFAULT '',
+  offer_ts VARCHAR(32) NOT NULL DEFAULT '',
+  created_at BIGINT NOT NULL,
+  INDEX idx_sandbox_waitlist_project (project, status, created_at),
+  INDEX idx_sandbox_waitlist_offer (status, offer_expires_at)
+);
@@ -0,0 +1,2 @@
+-- When the holder of a sandbox claim was reminded the claim is about to expire, 0 means not yet.
+ALTER TABLE testing_status ADD COLUMN claim_reminded_at BIGINT NOT NULL DEFAULT 0;
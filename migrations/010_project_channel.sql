@@ -0,0 +1,2 @@
+-- Channel a project posts its notices to, e.g. sandbox builds, empty means SANDBOX_CHANNEL.
+ALTER TABLE projects ADD COLUMN slack_channel VARCHAR(32) NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+-- Sandbox servers are managed from the bot, a disabled server is listed but cannot be claimed.
+ALTER TABLE testing_status
+  ADD COLUMN enabled TINYINT(1) NOT NULL DEFAULT 1,
+  ADD COLUMN url VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN description VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN owner_team VARCHAR(64) NOT NULL DEFAULT '';
+
+ALTER TABLE testing_status ADD UNIQUE INDEX idx_testing_status_server (project, server_id);
@@ -0,0 +1,8 @@
+-- Optional health endpoint of a sandbox server, the bot probe it and keep the last result.
+ALTER TABLE testing_status
+  ADD COLUMN health_url VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN health_up TINYINT(1) NOT NULL DEFAULT 0,
+  ADD COLUMN health_ms INT NOT NULL DEFAULT 0,
+  ADD COLUMN health_version VARCHAR(64) NOT NULL DEFAULT '',
+  ADD COLUMN health_error VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN health_checked_at BIGINT NOT NULL DEFAULT 0;
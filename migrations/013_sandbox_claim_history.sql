@@ -0,0 +1,18 @@
+-- Every claim of a sandbox server from the bot, testing_status only keep the current one.
+-- ended_at is 0 while the claim is open.
+CREATE TABLE IF NOT EXISTS sandbox_claim_history (
+  id VARCHAR(36) NOT NULL PRIMARY KEY,
+  project VARCHAR(255) NOT NULL,
+  server_id VARCHAR(64) NOT NULL,
+  slack_id VARCHAR(32) NOT NULL,
+  user_name VARCHAR(255) NOT NULL,
+  reason VARCHAR(255) NOT NULL DEFAULT '',
+  started_at BIGINT NOT NULL,
+  ended_at BIGINT NOT NULL DEFAULT 0,
+  ended_by VARCHAR(255) NOT NULL DEFAULT '',
+  INDEX idx_sandbox_claim_history_project (project, started_at),
+  INDEX idx_sandbox_claim_history_open (project, server_id, ended_at)
+);
+
+-- When a waitlist entry left the queue, 0 while it is waiting or offered.
+ALTER TABLE sandbox_waitlist ADD COLUMN closed_at BIGINT NOT NULL DEFAULT 0;
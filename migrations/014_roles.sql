@@ -0,0 +1,19 @@
+-- Named roles replace the magic slack_user_access.roles int (0 user, 1 admin, 2 sandbox only).
+-- roles is kept so an older bot can still run against the database.
+-- Every user already in the access list could release, so every legacy row become at least releaser,
+-- admin stay admin. Someone meant to only use the sandbox is lowered with set access role.
+-- A user not in the list is now a viewer, DEFAULT_ROLE=sandbox open the sandbox servers to everyone.
+ALTER TABLE slack_user_access ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'releaser';
+UPDATE slack_user_access SET role = CASE roles WHEN 1 THEN 'admin' ELSE 'releaser' END;
+
+-- Extra role of a user on a project and environment, '*' match every project or environment.
+CREATE TABLE IF NOT EXISTS slack_user_grant (
+  id VARCHAR(36) NOT NULL PRIMARY KEY,
+  slack_id VARCHAR(32) NOT NULL,
+  role VARCHAR(16) NOT NULL,
+  project VARCHAR(255) NOT NULL DEFAULT '*',
+  environment VARCHAR(32) NOT NULL DEFAULT '*',
+  granted_by VARCHAR(255) NOT NULL,
+  granted_at BIGINT NOT NULL,
+  UNIQUE INDEX idx_slack_user_grant (slack_id, project, environment)
+);
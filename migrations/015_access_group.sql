@@ -0,0 +1,17 @@
+-- A Slack user group bound to a role on a project and environment, its members are synced into slack_user_grant.
+CREATE TABLE IF NOT EXISTS slack_group_binding (
+  id VARCHAR(36) NOT NULL PRIMARY KEY,
+  group_id VARCHAR(32) NOT NULL,
+  group_handle VARCHAR(255) NOT NULL DEFAULT '',
+  role VARCHAR(16) NOT NULL,
+  project VARCHAR(255) NOT NULL DEFAULT '*',
+  environment VARCHAR(32) NOT NULL DEFAULT '*',
+  created_by VARCHAR(255) NOT NULL,
+  created_at BIGINT NOT NULL,
+  synced_at BIGINT NOT NULL DEFAULT 0,
+  member_count INT NOT NULL DEFAULT 0,
+  UNIQUE INDEX idx_slack_group_binding (group_id, project, environment)
+);
+
+-- The binding a grant was synced from, empty for a grant given by hand which always win over a group.
+ALTER TABLE slack_user_grant ADD COLUMN source VARCHAR(36) NOT NULL DEFAULT '';
@@ -0,0 +1,20 @@
+-- Append-only audit of every command, button, modal and change the bot made by itself.
+-- arguments are stored with the secrets redacted.
+CREATE TABLE IF NOT EXISTS audit_log (
+  id VARCHAR(36) NOT NULL PRIMARY KEY,
+  actor_id VARCHAR(32) NOT NULL DEFAULT '',
+  actor_name VARCHAR(255) NOT NULL DEFAULT '',
+  command VARCHAR(255) NOT NULL,
+  arguments TEXT NOT NULL,
+  channel VARCHAR(32) NOT NULL DEFAULT '',
+  outcome VARCHAR(16) NOT NULL,
+  created_at BIGINT NOT NULL,
+  INDEX idx_audit_log_created (created_at),
+  INDEX idx_audit_log_actor (actor_id, created_at)
+);
+
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log FOR EACH ROW
+  SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
+
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log FOR EACH ROW
+  SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
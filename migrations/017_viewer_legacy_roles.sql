@@ -0,0 +1,3 @@
+-- Viewers were written with the legacy roles 0, which an older bot read as a regular user.
+-- They now get 3, none of the legacy 0 user, 1 admin, 2 sandbox only.
+UPDATE slack_user_access SET roles = 3 WHERE role = 'viewer';
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit outcome, failed is a command that ran but answered with an error
+const (
+	AuditOk      = "ok"
+	AuditFailed  = "failed"
+	AuditDenied  = "denied"
+	AuditInvalid = "invalid"
+)
+
+// AuditRedacted replace a secret in the audit arguments
+const AuditRedacted = "[redacted]"
+
+// AuditLog is who did what, from where and how it ended, the table is append-only
+type AuditLog struct {
+	Id        string `json:"id"`
+	ActorId   string `json:"actor_id"`
+	ActorName string `json:"actor_name"`
+	Command   string `json:"command"`
+	Arguments string `json:"arguments"`
+	Channel   string `json:"channel"`
+	Outcome   string `json:"outcome"`
+	CreatedAt int    `json:"created_at"`
+}
+
+// AuditFilter narrow the audit log, an empty field match everything
+type AuditFilter struct {
+	ActorId string
+	// Text is searched in the actor name, command, arguments and channel
+	Text  string
+	Since time.Time
+}
+
+const auditLogColumns = "id, actor_id, actor_name, command, arguments, channel, outcome, created_at"
+
+func (a *AuditLog) scanFields() []any {
+	return []any{&a.Id, &a.ActorId, &a.ActorName, &a.Command, &a.Arguments, &a.Channel, &a.Outcome, &a.CreatedAt}
+}
+
+// secretValue match key=value or "key":"value" pairs whose key look like a secret, a quoted value is
+// matched up to its closing quote so a secret with a space is hidden whole
+var secretValue = regexp.MustCompile(`(?i)(\w*(?:token|secret|password|passwd|api[_-]?key)"?\s*[:=]\s*)(?:"[^"]*"|[^\s"&,|}]+)`)
+
+// secretToken match the well known token formats wherever they appear
+var secretToken = regexp.MustCompile(`\b(?:xox[abposr]-[\w-]+|xapp-[\w-]+|ghp_\w+|github_pat_\w+|glpat-[\w-]+)`)
+
+// RedactSecrets hide the values that look like a secret
+func RedactSecrets(Text string) string {
+	Text = secretValue.ReplaceAllStringFunc(Text, func(pair string) string {
+		match := secretValue.FindStringSubmatch(pair)
+		if strings.HasPrefix(pair[len(match[1]):], `"`) {
+			return match[1] + `"` + AuditRedacted + `"`
+		}
+		return match[1] + AuditRedacted
+	})
+	return secretToken.ReplaceAllString(Text, AuditRedacted)
+}
+
+// AddAudit append an entry to the audit log, the arguments are redacted
+func AddAudit(Entry AuditLog) {
+	_, err := DB.Exec("INSERT INTO audit_log ("+auditLogColumns+") values (?,?,?,?,?,?,?,?)", uuid.New().String(), strings.ToUpper(Entry.ActorId), Entry.ActorName, Entry.Command, RedactSecrets(Entry.Arguments), Entry.Channel, Entry.Outcome, time.Now().Unix())
+	if err != nil {
+		log.Print(err.Error())
+	}
+}
+
+// GetAuditLog return the entries matching the filter, the newest first
+func GetAuditLog(Filter AuditFilter, Limit int) []AuditLog {
+	query := "SELECT " + auditLogColumns + " FROM audit_log WHERE created_at >= ?"
+	args := []any{Filter.Since.Unix()}
+	if Filter.ActorId != "" {
+		query += " AND actor_id = ?"
+		args = append(args, strings.ToUpper(Filter.ActorId))
+	}
+	if Filter.Text != "" {
+		like := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(Filter.Text) + "%"
+		query += " AND (actor_name LIKE ? OR command LIKE ? OR arguments LIKE ? OR channel LIKE ?)"
+		args = append(args, like, like, like, like)
+	}
+	query += " order by created_at desc limit ?"
+	args = append(args, Limit)
+
+	results, err := DB.Query(query, args...)
+	if err != nil {
+		log.Print(err.Error())
+		return nil
+	}
+	defer results.Close()
+
+	var entries []AuditLog
+	for results.Next() {
+		var auditLog AuditLog
+
+		err = results.Scan(auditLog.scanFields()...)
+		if err != nil {
+			log.Print(err.Error())
+			continue
+		}
+		entries = append(entries, auditLog)
+	}
+
+	return entries
+}
@@ -0,0 +1,33 @@
+package models
+
+import "testing"
+
+func TestRedactSecrets(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"nothing to redact", "release grip <<1.2.0>>", "release grip <<1.2.0>>"},
+		{"pair at the start", "token=abc123 grip", "token=[redacted] grip"},
+		{"pair in the middle", "deploy grip jenkins_token=abc123 fe=main", "deploy grip jenkins_token=[redacted] fe=main"},
+		{"pair at the end", "set webhook grip secret=abc123", "set webhook grip secret=[redacted]"},
+		{"colon and case", "PASSWORD: hunter2, user: grip", "PASSWORD: [redacted], user: grip"},
+		{"query string", "https://ci.example.com/invoke?token=abc123&project=grip", "https://ci.example.com/invoke?token=[redacted]&project=grip"},
+		{"several pairs", "api_key=k1 passwd=p1 apikey=k2", "api_key=[redacted] passwd=[redacted] apikey=[redacted]"},
+		{"json value", `{"owner":"grip","token":"abc123","ref":"main"}`, `{"owner":"grip","token":"[redacted]","ref":"main"}`},
+		{"json value with spaces", `{"password": "correct horse battery", "ref": "main"}`, `{"password": "[redacted]", "ref": "main"}`},
+		{"json value last", `{"api-key":"k1"}`, `{"api-key":"[redacted]"}`},
+		{"env name is not a secret", `{"token_env":"GITHUB_TOKEN","repo":"grip"}`, `{"token_env":"GITHUB_TOKEN","repo":"grip"}`},
+		{"slack token at the start", "xoxb-1234-abcd is the bot token", "[redacted] is the bot token"},
+		{"github token in the middle", "use ghp_abc123 for grip", "use [redacted] for grip"},
+		{"gitlab token at the end", "trigger with glpat-abc_123", "trigger with [redacted]"},
+		{"known token inside a pair", "token=xapp-1-abc", "token=[redacted]"},
+	}
+
+	for _, test := range tests {
+		if got := RedactSecrets(test.text); got != test.want {
+			t.Errorf("%s: RedactSecrets(%q) = %q, want %q", test.name, test.text, got, test.want)
+		}
+	}
+}
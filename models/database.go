@@ -26,7 +26,8 @@ func ConnectDatabase() {
 		config.GetConfig("MYSQL_PASSWORD"),
 	}
 
-	connectionString := config.dbUser + ":" + config.dbPassword + "@tcp(" + config.dbHost + ":" + config.dbPort + ")/" + config.dbName
+	// clientFoundRows make RowsAffected count the matched rows, so an update that change nothing still find its row
+	connectionString := config.dbUser + ":" + config.dbPassword + "@tcp(" + config.dbHost + ":" + config.dbPort + ")/" + config.dbName + "?clientFoundRows=true"
 
 	db, err := sql.Open("mysql", connectionString)
 
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// TimeLayout is how the bot render a date time to the user
+const TimeLayout = "Mon, 02 Jan 2006 03:04PM"
+
+// FormatTime render a unix timestamp in Location with the zone name
+func FormatTime(Unix int, Location *time.Location) string {
+	return time.Unix(int64(Unix), 0).In(Location).Format(TimeLayout) + " (" + Location.String() + ")"
+}
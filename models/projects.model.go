@@ -15,27 +15,38 @@ type Projects struct {
 	Status       bool   `json:"status"`
 	JenkinsToken string `json:"jenkins_token"`
 	JenkinsHost  string `json:"jenkins_host"`
+	Timezone     string `json:"timezone"`
+	// Deployer is jenkins, gitlab, github or webhook, DeployerConfig is the JSON config of the non jenkins one
+	Deployer       string `json:"deployer"`
+	DeployerConfig string `json:"deployer_config"`
+	// SlackChannel is where the project notices are posted
+	SlackChannel string `json:"slack_channel"`
 }
 
-func AddNewProject(ProjectName string, ProjectToken string, JenkinsHost string) {
-	_, err := DB.Query("INSERT INTO projects values (?,?,?,?,?)", uuid.New(), strings.ToLower(ProjectName), true, strings.ToUpper(ProjectToken), JenkinsHost)
+// AddNewProject register an enabled jenkins project, false when it could not be saved
+func AddNewProject(ProjectName string, ProjectToken string, JenkinsHost string) bool {
+	_, err := DB.Exec("INSERT INTO projects (id, project_name, status, jenkins_token, jenkins_host) values (?,?,?,?,?)", uuid.New(), strings.ToLower(ProjectName), true, ProjectToken, JenkinsHost)
 	if err != nil {
 		log.Print(err.Error())
+		return false
 	}
+	return true
 }
 
 func GetAllProjects() string {
-	results, err := DB.Query("SELECT project_name, status, jenkins_token, jenkins_host FROM projects order by project_name ASC;")
+	results, err := DB.Query("SELECT project_name, status, jenkins_token, jenkins_host, timezone, deployer FROM projects order by project_name ASC;")
 	if err != nil {
 		log.Print(err.Error())
+		return ""
 	}
+	defer results.Close()
 
 	tempList := ""
 	i := 1
 	for results.Next() {
 		var projects Projects
 
-		err = results.Scan(&projects.ProjectName, &projects.Status, &projects.JenkinsToken, &projects.JenkinsHost)
+		err = results.Scan(&projects.ProjectName, &projects.Status, &projects.JenkinsToken, &projects.JenkinsHost, &projects.Timezone, &projects.Deployer)
 
 		if err != nil {
 			log.Print(err.Error())
@@ -45,25 +56,41 @@ func GetAllProjects() string {
 		if !projects.Status {
 			tempStatus = "Disabled"
 		}
-		tempList += fmt.Sprintf("%s. *%s* : %s - %s (%s) \n\n", strconv.Itoa(i), projects.ProjectName, projects.JenkinsToken, projects.JenkinsHost, tempStatus)
+		tempTimezone := ""
+		if projects.Timezone != "" {
+			tempTimezone = " [" + projects.Timezone + "]"
+		}
+		if projects.Deployer != "" && projects.Deployer != "jenkins" {
+			tempList += fmt.Sprintf("%s. *%s* : %s deployer (%s)%s \n\n", strconv.Itoa(i), projects.ProjectName, projects.Deployer, tempStatus, tempTimezone)
+		} else {
+			// the list is posted in channels, the token is only shown to an admin by test project
+			tempList += fmt.Sprintf("%s. *%s* : %s - %s (%s)%s \n\n", strconv.Itoa(i), projects.ProjectName, AuditRedacted, projects.JenkinsHost, tempStatus, tempTimezone)
+		}
 		i++
 	}
 
 	return tempList
 }
 
-func DeleteProject(ProjectName string) {
-	_, err := DB.Query("DELETE from projects where project_name = ?", strings.ToLower(ProjectName))
-	if err != nil {
-		log.Print(err.Error())
-	}
+// DeleteProject remove a project, false when it does not exist
+func DeleteProject(ProjectName string) bool {
+	return execProject("DELETE from projects where project_name = ?", strings.ToLower(ProjectName))
+}
+
+// ToogleProject enable or disable a project, false when it does not exist
+func ToogleProject(ProjectName string, ProjectStatus bool) bool {
+	return execProject("UPDATE projects set status = ? where project_name = ?", ProjectStatus, strings.ToLower(ProjectName))
 }
 
-func ToogleProject(ProjectName string, ProjectStatus bool) {
-	_, err := DB.Query("UPDATE projects set status = ? where project_name = ?", ProjectStatus, strings.ToLower(ProjectName))
+// execProject run an update on a single project and report if a row was found
+func execProject(Query string, Args ...any) bool {
+	result, err := DB.Exec(Query, Args...)
 	if err != nil {
 		log.Print(err.Error())
+		return false
 	}
+	affected, err := result.RowsAffected()
+	return err == nil && affected > 0
 }
 
 func ProjectIsAvailable(ProjectName string) bool {
@@ -99,3 +126,86 @@ func GetProjectJenkinsHost(ProjectName string) string {
 
 	return projects.JenkinsHost
 }
+
+// GetProjectTimezone return the project default timezone, empty when not set
+func GetProjectTimezone(ProjectName string) string {
+	var projects Projects
+
+	err := DB.QueryRow("Select timezone from projects where project_name = ?", strings.ToLower(ProjectName)).Scan(&projects.Timezone)
+
+	if err != nil {
+		log.Print(err.Error())
+		return ""
+	}
+
+	return projects.Timezone
+}
+
+func SetProjectTimezone(ProjectName string, Timezone string) {
+	_, err := DB.Exec("UPDATE projects set timezone = ? where project_name = ?", Timezone, strings.ToLower(ProjectName))
+	if err != nil {
+		log.Print(err.Error())
+	}
+}
+
+// GetProjectDeployer return the deployer kind and its JSON config, empty kind is jenkins
+func GetProjectDeployer(ProjectName string) (string, string) {
+	var projects Projects
+
+	err := DB.QueryRow("Select deployer, COALESCE(deployer_config, '') from projects where project_name = ? and status = 1", strings.ToLower(ProjectName)).Scan(&projects.Deployer, &projects.DeployerConfig)
+
+	if err != nil {
+		log.Print(err.Error())
+		return "", ""
+	}
+
+	return projects.Deployer, projects.DeployerConfig
+}
+
+func SetProjectDeployer(ProjectName string, Deployer string, DeployerConfig string) {
+	_, err := DB.Exec("UPDATE projects set deployer = ?, deployer_config = ? where project_name = ?", Deployer, DeployerConfig, strings.ToLower(ProjectName))
+	if err != nil {
+		log.Print(err.Error())
+	}
+}
+
+// GetProjectChannel return the channel id of the project notices, empty when not set
+func GetProjectChannel(ProjectName string) string {
+	var projects Projects
+
+	err := DB.QueryRow("Select slack_channel from projects where project_name = ?", strings.ToLower(ProjectName)).Scan(&projects.SlackChannel)
+
+	if err != nil {
+		return ""
+	}
+
+	return projects.SlackChannel
+}
+
+func SetProjectChannel(ProjectName string, SlackChannel string) {
+	_, err := DB.Exec("UPDATE projects set slack_channel = ? where project_name = ?", SlackChannel, strings.ToLower(ProjectName))
+	if err != nil {
+		log.Print(err.Error())
+	}
+}
+
+// GetEnabledProjectNames return the name of every enabled project, e.g. for a project dropdown
+func GetEnabledProjectNames() []string {
+	results, err := DB.Query("SELECT project_name FROM projects WHERE status = 1 order by project_name ASC")
+	if err != nil {
+		log.Print(err.Error())
+		return nil
+	}
+	defer results.Close()
+
+	var names []string
+	for results.Next() {
+		var projects Projects
+		if err := results.Scan(&projects.ProjectName); err != nil {
+			log.Print(err.Error())
+			continue
+		}
+		names = append(names, projects.ProjectName)
+	}
+	return names
+}
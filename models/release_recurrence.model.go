@@ -0,0 +1,132 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RecurrenceActive  = "active"
+	RecurrencePaused  = "paused"
+	RecurrenceDeleted = "deleted"
+)
+
+// ReleaseRecurrence is a cron schedule that create a release_schedule row every time it is due
+type ReleaseRecurrence struct {
+	Id             string `json:"id"`
+	CronExpr       string `json:"cron_expr"`
+	Timezone       string `json:"timezone"`
+	ReleaseProject string `json:"release_project"`
+	ReleaseVersion string `json:"release_version"`
+	Status         string `json:"status"`
+	LastRunOn      int    `json:"last_run_on"`
+	NextRunOn      int    `json:"next_run_on"`
+	CreatedAt      int    `json:"created_at"`
+	CreatedBy      string `json:"created_by"`
+	// Channel is where every run is reported
+	Channel string `json:"channel"`
+}
+
+const releaseRecurrenceColumns = "id, cron_expr, timezone, release_project, release_version, status, last_run_on, next_run_on, created_at, created_by, channel"
+
+func (r *ReleaseRecurrence) scanFields() []any {
+	return []any{&r.Id, &r.CronExpr, &r.Timezone, &r.ReleaseProject, &r.ReleaseVersion, &r.Status, &r.LastRunOn, &r.NextRunOn, &r.CreatedAt, &r.CreatedBy, &r.Channel}
+}
+
+func CreateRecurrence(Project string, Version string, CronExpr string, Timezone string, NextRunOn time.Time, CreatedBy string, Channel string) string {
+	id := uuid.New().String()
+	_, err := DB.Exec("INSERT INTO release_recurrence ("+releaseRecurrenceColumns+") values (?,?,?,?,?,?,?,?,?,?,?)", id, CronExpr, Timezone, strings.ToLower(Project), Version, RecurrenceActive, 0, NextRunOn.Unix(), time.Now().Unix(), CreatedBy, Channel)
+	if err != nil {
+		log.Print(err.Error())
+		return ""
+	}
+	return id
+}
+
+// GetRecurrence return the recurrence that is not deleted
+func GetRecurrence(Id string) (ReleaseRecurrence, bool) {
+	var releaseRecurrence ReleaseRecurrence
+
+	err := DB.QueryRow("Select "+releaseRecurrenceColumns+" from release_recurrence where id = ? and status != ?", Id, RecurrenceDeleted).Scan(releaseRecurrence.scanFields()...)
+
+	return releaseRecurrence, err == nil
+}
+
+// GetDueRecurrence return the active recurrence that should run at Now
+func GetDueRecurrence(Now time.Time) []ReleaseRecurrence {
+	results, err := DB.Query("SELECT "+releaseRecurrenceColumns+" FROM release_recurrence WHERE status = ? AND next_run_on <= ?", RecurrenceActive, Now.Unix())
+	if err != nil {
+		log.Print(err.Error())
+		return nil
+	}
+	defer results.Close()
+
+	var recurrences []ReleaseRecurrence
+	for results.Next() {
+		var releaseRecurrence ReleaseRecurrence
+
+		err = results.Scan(releaseRecurrence.scanFields()...)
+		if err != nil {
+			log.Print(err.Error())
+			continue
+		}
+		recurrences = append(recurrences, releaseRecurrence)
+	}
+
+	return recurrences
+}
+
+func UpdateRecurrenceRun(Id string, LastRunOn time.Time, NextRunOn time.Time) {
+	_, err := DB.Exec("UPDATE release_recurrence set last_run_on = ?, next_run_on = ? where id = ?", LastRunOn.Unix(), NextRunOn.Unix(), Id)
+	if err != nil {
+		log.Print(err.Error())
+	}
+}
+
+// SetRecurrenceStatus pause, resume or delete a recurrence, deleted row are kept for the history
+func SetRecurrenceStatus(Id string, Status string, NextRunOn time.Time) {
+	_, err := DB.Exec("UPDATE release_recurrence set status = ?, next_run_on = ? where id = ?", Status, NextRunOn.Unix(), Id)
+	if err != nil {
+		log.Print(err.Error())
+	}
+}
+
+// GetActiveRecurrence list the recurrence that are not deleted with the next run in the viewer Location
+func GetActiveRecurrence(Location *time.Location) string {
+	results, err := DB.Query("SELECT "+releaseRecurrenceColumns+" FROM release_recurrence WHERE status != ? order by next_run_on", RecurrenceDeleted)
+	if err != nil {
+		log.Print(err.Error())
+		return ""
+	}
+	defer results.Close()
+
+	tempList := ""
+	i := 1
+	for results.Next() {
+		var releaseRecurrence ReleaseRecurrence
+
+		err = results.Scan(releaseRecurrence.scanFields()...)
+		if err != nil {
+			log.Print(err.Error())
+			continue
+		}
+
+		tempNext := FormatTime(releaseRecurrence.NextRunOn, Location)
+		if releaseRecurrence.Status == RecurrencePaused {
+			tempNext = "paused"
+		}
+		tempLast := "never"
+		if releaseRecurrence.LastRunOn > 0 {
+			tempLast = FormatTime(releaseRecurrence.LastRunOn, Location)
+		}
+		tempList += fmt.Sprintf("%s. *%s* > %s \n\t Every: `%s` (%s) \n\t Next run: %s \n\t Last run: %s \n\t Id: %s \n\t Created by: %s \n\n", strconv.Itoa(i), releaseRecurrence.ReleaseProject, releaseRecurrence.ReleaseVersion, releaseRecurrence.CronExpr, releaseRecurrence.Timezone, tempNext, tempLast, releaseRecurrence.Id, releaseRecurrence.CreatedBy)
+		i++
+	}
+
+	return tempList
+}
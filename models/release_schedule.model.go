@@ -12,51 +12,92 @@ import (
 
 type ReleaseSchedule struct {
 	Id             string `json:"id"`
-	ReleaseOn      string `json:"release_on"`
+	ReleaseOn      int    `json:"release_on"`
 	ReleaseProject string `json:"release_project"`
 	ReleaseVersion string `json:"release_version"`
-	Released       int    `json:"released"`
+	State          string `json:"state"`
+	StateChangedAt int    `json:"state_changed_at"`
 	CreatedAt      int    `json:"created_at"`
 	CreatedBy      string `json:"created_by"`
+	RecurrenceId   string `json:"recurrence_id"`
+	// Channel and ThreadTs is where the release was requested, progress is reported there
+	Channel  string `json:"channel"`
+	ThreadTs string `json:"thread_ts"`
 }
 
-func CreateSchedule(Project string, Version string, EndTime string, CreatedBy string) {
+const releaseScheduleColumns = "id, release_on, release_project, release_version, state, state_changed_at, created_at, created_by, recurrence_id, channel, thread_ts"
+
+func (r *ReleaseSchedule) scanFields() []any {
+	return []any{&r.Id, &r.ReleaseOn, &r.ReleaseProject, &r.ReleaseVersion, &r.State, &r.StateChangedAt, &r.CreatedAt, &r.CreatedBy, &r.RecurrenceId, &r.Channel, &r.ThreadTs}
+}
+
+// CreateSchedule create a pending release and return the id
+func CreateSchedule(Project string, Version string, ReleaseOn time.Time, CreatedBy string, Channel string, ThreadTs string) (string, error) {
+	return createSchedule(strings.ToLower(Project), Version, ReleaseOn, CreatedBy, "", Channel, ThreadTs)
+}
+
+// CreateRecurringSchedule create the one time schedule for a single run of a recurrence
+func CreateRecurringSchedule(Recurrence ReleaseRecurrence, ReleaseOn time.Time) (string, error) {
+	return createSchedule(Recurrence.ReleaseProject, Recurrence.ReleaseVersion, ReleaseOn, Recurrence.CreatedBy, Recurrence.Id, Recurrence.Channel, "")
+}
+
+func createSchedule(Project string, Version string, ReleaseOn time.Time, CreatedBy string, RecurrenceId string, Channel string, ThreadTs string) (string, error) {
+	id := uuid.New().String()
+	now := time.Now().Unix()
+
 	//write to db
-	_, err := DB.Query("INSERT INTO release_schedule values (?,?,?,?,?,?,?)", uuid.New(), strings.ToUpper(EndTime), Project, Version, 0, time.Now().Unix(), CreatedBy)
+	_, err := DB.Exec("INSERT INTO release_schedule ("+releaseScheduleColumns+") values (?,?,?,?,?,?,?,?,?,?,?)", id, ReleaseOn.Unix(), Project, Version, ReleasePending, now, now, CreatedBy, RecurrenceId, Channel, ThreadTs)
 	if err != nil {
 		log.Print(err.Error())
+		return "", err
 	}
+
+	logTransition(id, "", ReleasePending, CreatedBy, "")
+	return id, nil
 }
 
-func UpdateReleased(Id string) {
-	_, err := DB.Query("UPDATE release_schedule set released = 1 where id = ?", Id)
-	if err != nil {
-		log.Print(err.Error())
-	}
+// GetSchedule return a single schedule by id
+func GetSchedule(Id string) (ReleaseSchedule, bool) {
+	var releaseSchedule ReleaseSchedule
+
+	err := DB.QueryRow("Select "+releaseScheduleColumns+" from release_schedule where id = ?", Id).Scan(releaseSchedule.scanFields()...)
+
+	return releaseSchedule, err == nil
+}
+
+// GetDueRelease return the pending schedule that should be released at Now
+func GetDueRelease(Now time.Time) []ReleaseSchedule {
+	return querySchedules("SELECT "+releaseScheduleColumns+" FROM release_schedule WHERE state = ? AND release_on <= ? order by release_on", ReleasePending, Now.Unix())
 }
 
-func GetActiveRelease() string {
-	results, err := DB.Query("SELECT * FROM release_schedule WHERE released = 0")
+// GetActiveRelease list the pending schedule with the time in the viewer Location,
+// the project timezone is added when the project has one and it is different
+func GetActiveRelease(Location *time.Location) string {
+	results, err := DB.Query("SELECT rs.id, rs.release_on, rs.release_project, rs.release_version, rs.state, rs.state_changed_at, rs.created_at, rs.created_by, rs.recurrence_id, rs.channel, rs.thread_ts, COALESCE(p.timezone, '') FROM release_schedule rs LEFT JOIN projects p ON p.project_name = rs.release_project WHERE rs.state = ? order by rs.release_on", ReleasePending)
 	if err != nil {
 		log.Print(err.Error())
+		return ""
 	}
+	defer results.Close()
 
 	tempList := ""
 	i := 1
 	for results.Next() {
 		var releaseSchedule ReleaseSchedule
+		var projectTimezone string
 
 		//map to struct
-		err = results.Scan(&releaseSchedule.Id, &releaseSchedule.ReleaseOn, &releaseSchedule.ReleaseProject, &releaseSchedule.ReleaseVersion, &releaseSchedule.Released, &releaseSchedule.CreatedAt, &releaseSchedule.CreatedBy)
+		err = results.Scan(append(releaseSchedule.scanFields(), &projectTimezone)...)
 
 		if err != nil {
 			log.Print(err.Error())
 		}
 
-		temp, _ := strconv.ParseInt(strconv.Itoa(releaseSchedule.CreatedAt), 10, 64)
-		location, _ := time.LoadLocation("Asia/Singapore")
-		tempDate := time.Unix(temp, 0).In(location)
-		tempList += fmt.Sprintf("%s. *%s* > %s \n\t Will be release on: %s (Asia/Singapore) \n\t Id: %s \n\t Created by: %s \n\t Created on: %s \n\n", strconv.Itoa(i), releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion, releaseSchedule.ReleaseOn, releaseSchedule.Id, releaseSchedule.CreatedBy, tempDate.String())
+		releaseDate := FormatTime(releaseSchedule.ReleaseOn, Location)
+		if projectLocation, err := time.LoadLocation(projectTimezone); projectTimezone != "" && err == nil && projectLocation.String() != Location.String() {
+			releaseDate += " / " + FormatTime(releaseSchedule.ReleaseOn, projectLocation)
+		}
+		tempList += fmt.Sprintf("%s. *%s* > %s \n\t Will be release on: %s \n\t Id: %s \n\t Created by: %s \n\t Created on: %s \n\n", strconv.Itoa(i), releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion, releaseDate, releaseSchedule.Id, releaseSchedule.CreatedBy, FormatTime(releaseSchedule.CreatedAt, Location))
 		i++
 	}
 
@@ -66,7 +107,97 @@ func GetActiveRelease() string {
 func CheckActiveRelease(Id string) bool {
 	var releaseSchedule ReleaseSchedule
 
-	err := DB.QueryRow("Select * from release_schedule where id = ? and released = 0", Id).Scan(&releaseSchedule.Id, &releaseSchedule.ReleaseOn, &releaseSchedule.ReleaseProject, &releaseSchedule.ReleaseVersion, &releaseSchedule.Released, &releaseSchedule.CreatedAt, &releaseSchedule.CreatedBy)
+	err := DB.QueryRow("Select "+releaseScheduleColumns+" from release_schedule where id = ? and state = ?", Id, ReleasePending).Scan(releaseSchedule.scanFields()...)
 
 	return err == nil
 }
+
+// GetReleaseHistory list the latest release that left the pending state, Project empty means all project
+func GetReleaseHistory(Project string, Limit int, Location *time.Location) string {
+	results, err := DB.Query("SELECT "+releaseScheduleColumns+" FROM release_schedule WHERE state != ? AND (? = '' OR release_project = ?) order by release_on desc limit ?", ReleasePending, strings.ToLower(Project), strings.ToLower(Project), Limit)
+	if err != nil {
+		log.Print(err.Error())
+		return ""
+	}
+
+	var schedules []ReleaseSchedule
+	for results.Next() {
+		var releaseSchedule ReleaseSchedule
+
+		err = results.Scan(releaseSchedule.scanFields()...)
+		if err != nil {
+			log.Print(err.Error())
+			continue
+		}
+		schedules = append(schedules, releaseSchedule)
+	}
+	results.Close()
+
+	tempList := ""
+	for i, releaseSchedule := range schedules {
+		tempRecurring := ""
+		if releaseSchedule.RecurrenceId != "" {
+			tempRecurring = " (recurring)"
+		}
+		tempList += fmt.Sprintf("%s. *%s* > %s%s is *%s* \n\t Release on: %s \n\t Id: %s \n", strconv.Itoa(i+1), releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion, tempRecurring, releaseSchedule.State, FormatTime(releaseSchedule.ReleaseOn, Location), releaseSchedule.Id)
+		for _, transition := range GetTransitions(releaseSchedule.Id) {
+			tempNote := ""
+			if transition.Note != "" {
+				tempNote = " - " + transition.Note
+			}
+			tempList += fmt.Sprintf("\t\t %s: %s by %s%s \n", FormatTime(transition.CreatedAt, Location), transition.ToState, transition.Actor, tempNote)
+		}
+		tempList += "\n"
+	}
+
+	return tempList
+}
+
+// GetLastRelease return the latest release of the project that succeeded or at least was triggered
+func GetLastRelease(Project string) (ReleaseSchedule, bool) {
+	var releaseSchedule ReleaseSchedule
+
+	err := DB.QueryRow("Select "+releaseScheduleColumns+" from release_schedule where release_project = ? and state in (?, ?) order by state_changed_at desc limit 1", strings.ToLower(Project), ReleaseSucceeded, ReleaseTriggered).Scan(releaseSchedule.scanFields()...)
+
+	return releaseSchedule, err == nil
+}
+
+// GetPendingSchedules return the pending schedules, the next release first
+func GetPendingSchedules(Limit int) []ReleaseSchedule {
+	return querySchedules("SELECT "+releaseScheduleColumns+" FROM release_schedule WHERE state = ? order by release_on limit ?", ReleasePending, Limit)
+}
+
+// GetRecentReleases return the schedules that left the pending state, the latest change first
+func GetRecentReleases(Limit int) []ReleaseSchedule {
+	return querySchedules("SELECT "+releaseScheduleColumns+" FROM release_schedule WHERE state != ? order by state_changed_at desc limit ?", ReleasePending, Limit)
+}
+
+// GetInterruptedReleases return the schedules a running bot was deploying, the oldest change first. That is the one
+// left firing, and the one triggered since Since with a deployment the bot was following, noted on the transition.
+// The release migrated as triggered from the released flag have no transition and are never returned
+func GetInterruptedReleases(Since time.Time) []ReleaseSchedule {
+	return querySchedules("SELECT "+releaseScheduleColumns+" FROM release_schedule rs WHERE rs.state = ? OR (rs.state = ? AND rs.state_changed_at >= ? AND EXISTS (SELECT 1 FROM release_transition rt WHERE rt.schedule_id = rs.id AND rt.to_state = ? AND rt.note != '')) order by rs.state_changed_at", ReleaseFiring, ReleaseTriggered, Since.Unix(), ReleaseTriggered)
+}
+
+func querySchedules(Query string, Args ...any) []ReleaseSchedule {
+	results, err := DB.Query(Query, Args...)
+	if err != nil {
+		log.Print(err.Error())
+		return nil
+	}
+	defer results.Close()
+
+	var schedules []ReleaseSchedule
+	for results.Next() {
+		var releaseSchedule ReleaseSchedule
+
+		err = results.Scan(releaseSchedule.scanFields()...)
+		if err != nil {
+			log.Print(err.Error())
+			continue
+		}
+		schedules = append(schedules, releaseSchedule)
+	}
+
+	return schedules
+}
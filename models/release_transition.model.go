@@ -0,0 +1,104 @@
+package models
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Release lifecycle states, a release move pending > firing > triggered > succeeded or failed,
+// a pending release can also be cancelled by a user or expired when it was not fired in time
+const (
+	ReleasePending   = "pending"
+	ReleaseFiring    = "firing"
+	ReleaseTriggered = "triggered"
+	ReleaseSucceeded = "succeeded"
+	ReleaseFailed    = "failed"
+	ReleaseCancelled = "cancelled"
+	ReleaseExpired   = "expired"
+)
+
+// BotActor is recorded as the actor for the change the bot did by itself
+const BotActor = "GRIP Release Bot"
+
+// releaseTransitions is the allowed next states of every state
+var releaseTransitions = map[string][]string{
+	ReleasePending:   {ReleaseFiring, ReleaseCancelled, ReleaseExpired},
+	ReleaseFiring:    {ReleaseTriggered, ReleaseFailed},
+	ReleaseTriggered: {ReleaseSucceeded, ReleaseFailed},
+}
+
+type ReleaseTransition struct {
+	Id         string `json:"id"`
+	ScheduleId string `json:"schedule_id"`
+	FromState  string `json:"from_state"`
+	ToState    string `json:"to_state"`
+	Actor      string `json:"actor"`
+	Note       string `json:"note"`
+	CreatedAt  int    `json:"created_at"`
+}
+
+// CanTransition tell if a release in From can move to To
+func CanTransition(From string, To string) bool {
+	for _, next := range releaseTransitions[From] {
+		if next == To {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionRelease move the release from From to To, it is false when the move is not allowed
+// or the release is not in From anymore, so only one caller can win a transition
+func TransitionRelease(Id string, From string, To string, Actor string, Note string) bool {
+	if !CanTransition(From, To) {
+		log.Println("invalid release transition", Id, From, To)
+		return false
+	}
+
+	result, err := DB.Exec("UPDATE release_schedule set state = ?, state_changed_at = ? where id = ? and state = ?", To, time.Now().Unix(), Id, From)
+	if err != nil {
+		log.Print(err.Error())
+		return false
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return false
+	}
+
+	logTransition(Id, From, To, Actor, Note)
+	AddAudit(AuditLog{ActorName: Actor, Command: "release " + To, Arguments: strings.TrimSpace(Id + " " + From + " > " + To + " " + Note), Outcome: AuditOk})
+	return true
+}
+
+func logTransition(ScheduleId string, From string, To string, Actor string, Note string) {
+	_, err := DB.Exec("INSERT INTO release_transition (id, schedule_id, from_state, to_state, actor, note, created_at) values (?,?,?,?,?,?,?)", uuid.New(), ScheduleId, From, To, Actor, Note, time.Now().Unix())
+	if err != nil {
+		log.Print(err.Error())
+	}
+}
+
+// GetTransitions return the transitions of a release oldest first
+func GetTransitions(ScheduleId string) []ReleaseTransition {
+	results, err := DB.Query("SELECT id, schedule_id, from_state, to_state, actor, note, created_at FROM release_transition WHERE schedule_id = ? order by created_at, FIELD(to_state, 'pending', 'firing', 'triggered', 'succeeded', 'failed', 'cancelled', 'expired')", ScheduleId)
+	if err != nil {
+		log.Print(err.Error())
+		return nil
+	}
+	defer results.Close()
+
+	var transitions []ReleaseTransition
+	for results.Next() {
+		var transition ReleaseTransition
+
+		err = results.Scan(&transition.Id, &transition.ScheduleId, &transition.FromState, &transition.ToState, &transition.Actor, &transition.Note, &transition.CreatedAt)
+		if err != nil {
+			log.Print(err.Error())
+			continue
+		}
+		transitions = append(transitions, transition)
+	}
+
+	return transitions
+}
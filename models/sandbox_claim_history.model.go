@@ -0,0 +1,68 @@
+package models
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SandboxClaimHistory is one claim of a sandbox server, EndedAt is 0 while it is open
+type SandboxClaimHistory struct {
+	Id        string `json:"id"`
+	Project   string `json:"project"`
+	ServerId  string `json:"server_id"`
+	SlackId   string `json:"slack_id"`
+	UserName  string `json:"user_name"`
+	Reason    string `json:"reason"`
+	StartedAt int    `json:"started_at"`
+	EndedAt   int    `json:"ended_at"`
+	EndedBy   string `json:"ended_by"`
+}
+
+const sandboxClaimHistoryColumns = "id, project, server_id, slack_id, user_name, reason, started_at, ended_at, ended_by"
+
+func (h *SandboxClaimHistory) scanFields() []any {
+	return []any{&h.Id, &h.Project, &h.ServerId, &h.SlackId, &h.UserName, &h.Reason, &h.StartedAt, &h.EndedAt, &h.EndedBy}
+}
+
+// openClaimHistory start the history of a claim, an extension by the holder keep the open one
+func openClaimHistory(Project string, ServerId string, SlackId string, Name string, Reason string) {
+	_, err := DB.Exec("INSERT INTO sandbox_claim_history ("+sandboxClaimHistoryColumns+") SELECT ?,?,?,?,?,?,?,?,? FROM DUAL WHERE NOT EXISTS (SELECT 1 FROM sandbox_claim_history WHERE project = ? AND server_id = ? AND ended_at = 0)", uuid.New().String(), Project, ServerId, SlackId, Name, Reason, time.Now().Unix(), 0, "", Project, ServerId)
+	if err != nil {
+		log.Print(err.Error())
+	}
+}
+
+// closeClaimHistory end the open claim of a server
+func closeClaimHistory(Project string, ServerId string, Name string) {
+	_, err := DB.Exec("UPDATE sandbox_claim_history set ended_at = ?, ended_by = ? where project = ? and server_id = ? and ended_at = 0", time.Now().Unix(), Name, Project, ServerId)
+	if err != nil {
+		log.Print(err.Error())
+	}
+}
+
+// GetClaimHistory return the claims of the project that overlap From and To
+func GetClaimHistory(Project string, From time.Time, To time.Time) []SandboxClaimHistory {
+	results, err := DB.Query("SELECT "+sandboxClaimHistoryColumns+" FROM sandbox_claim_history WHERE project = ? AND started_at < ? AND (ended_at = 0 OR ended_at > ?) order by started_at", strings.ToLower(Project), To.Unix(), From.Unix())
+	if err != nil {
+		log.Print(err.Error())
+		return nil
+	}
+	defer results.Close()
+
+	var claims []SandboxClaimHistory
+	for results.Next() {
+		var sandboxClaimHistory SandboxClaimHistory
+
+		err = results.Scan(sandboxClaimHistory.scanFields()...)
+		if err != nil {
+			log.Print(err.Error())
+			continue
+		}
+		claims = append(claims, sandboxClaimHistory)
+	}
+
+	return claims
+}
@@ -0,0 +1,178 @@
+package models
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Waitlist entry status, waiting and offered are the active one
+const (
+	WaitlistWaiting  = "waiting"
+	WaitlistOffered  = "offered"
+	WaitlistAccepted = "accepted"
+	WaitlistPassed   = "passed"
+	WaitlistExpired  = "expired"
+	WaitlistLeft     = "left"
+)
+
+// SandboxWaitlist is a user waiting for a sandbox server of a project
+type SandboxWaitlist struct {
+	Id       string `json:"id"`
+	Project  string `json:"project"`
+	SlackId  string `json:"slack_id"`
+	UserName string `json:"user_name"`
+	Status   string `json:"status"`
+	// ServerId, OfferExpiresAt and the offer message are set once a server is offered
+	ServerId       string `json:"server_id"`
+	OfferExpiresAt int    `json:"offer_expires_at"`
+	OfferChannel   string `json:"offer_channel"`
+	OfferTs        string `json:"offer_ts"`
+	CreatedAt      int    `json:"created_at"`
+	// ClosedAt is when the entry left the queue, 0 while it is active
+	ClosedAt int `json:"closed_at"`
+}
+
+const sandboxWaitlistColumns = "id, project, slack_id, user_name, status, server_id, offer_expires_at, offer_channel, offer_ts, created_at, closed_at"
+
+func (w *SandboxWaitlist) scanFields() []any {
+	return []any{&w.Id, &w.Project, &w.SlackId, &w.UserName, &w.Status, &w.ServerId, &w.OfferExpiresAt, &w.OfferChannel, &w.OfferTs, &w.CreatedAt, &w.ClosedAt}
+}
+
+// JoinWaitlist add the user at the end of the project waitlist and return the position,
+// false when the user is already waiting or being offered a server of the project
+func JoinWaitlist(Project string, SlackId string, UserName string) (int, bool) {
+	if position := GetWaitlistPosition(Project, SlackId); position > 0 {
+		return position, false
+	}
+
+	_, err := DB.Exec("INSERT INTO sandbox_waitlist ("+sandboxWaitlistColumns+") values (?,?,?,?,?,?,?,?,?,?,?)", uuid.New().String(), strings.ToLower(Project), strings.ToUpper(SlackId), UserName, WaitlistWaiting, "", 0, "", "", time.Now().Unix(), 0)
+	if err != nil {
+		log.Print(err.Error())
+		return 0, false
+	}
+	return GetWaitlistPosition(Project, SlackId), true
+}
+
+// LeaveWaitlist remove the user from the project waitlist, false when the user was not waiting
+func LeaveWaitlist(Project string, SlackId string) bool {
+	result, err := DB.Exec("UPDATE sandbox_waitlist set status = ?, closed_at = ? where project = ? and slack_id = ? and status = ?", WaitlistLeft, time.Now().Unix(), strings.ToLower(Project), strings.ToUpper(SlackId), WaitlistWaiting)
+	if err != nil {
+		log.Print(err.Error())
+		return false
+	}
+	affected, err := result.RowsAffected()
+	return err == nil && affected > 0
+}
+
+// GetWaitlistPosition is the 1 based position of the user in the project waitlist, 0 when not waiting
+func GetWaitlistPosition(Project string, SlackId string) int {
+	for i, entry := range GetWaitlist(Project) {
+		if entry.SlackId == strings.ToUpper(SlackId) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// GetWaitlist return the active entries of the project, the one being offered a server first
+func GetWaitlist(Project string) []SandboxWaitlist {
+	return queryWaitlist("SELECT "+sandboxWaitlistColumns+" FROM sandbox_waitlist WHERE project = ? AND status IN (?, ?) order by FIELD(status, ?, ?), created_at", strings.ToLower(Project), WaitlistWaiting, WaitlistOffered, WaitlistOffered, WaitlistWaiting)
+}
+
+// GetNextWaiting return the oldest waiting entry of the project
+func GetNextWaiting(Project string) (SandboxWaitlist, bool) {
+	var sandboxWaitlist SandboxWaitlist
+
+	err := DB.QueryRow("Select "+sandboxWaitlistColumns+" from sandbox_waitlist where project = ? and status = ? order by created_at limit 1", strings.ToLower(Project), WaitlistWaiting).Scan(sandboxWaitlist.scanFields()...)
+
+	return sandboxWaitlist, err == nil
+}
+
+// GetWaitlistEntry return a single entry by id
+func GetWaitlistEntry(Id string) (SandboxWaitlist, bool) {
+	var sandboxWaitlist SandboxWaitlist
+
+	err := DB.QueryRow("Select "+sandboxWaitlistColumns+" from sandbox_waitlist where id = ?", Id).Scan(sandboxWaitlist.scanFields()...)
+
+	return sandboxWaitlist, err == nil
+}
+
+// GetWaitlistOffer return the open offer of a server, false when the server is not offered
+func GetWaitlistOffer(Project string, ServerId string) (SandboxWaitlist, bool) {
+	var sandboxWaitlist SandboxWaitlist
+
+	err := DB.QueryRow("Select "+sandboxWaitlistColumns+" from sandbox_waitlist where project = ? and server_id = ? and status = ?", strings.ToLower(Project), ServerId, WaitlistOffered).Scan(sandboxWaitlist.scanFields()...)
+
+	return sandboxWaitlist, err == nil
+}
+
+// GetExpiredOffers return the offers nobody answered before Now
+func GetExpiredOffers(Now time.Time) []SandboxWaitlist {
+	return queryWaitlist("SELECT "+sandboxWaitlistColumns+" FROM sandbox_waitlist WHERE status = ? AND offer_expires_at <= ?", WaitlistOffered, Now.Unix())
+}
+
+// OfferWaitlist move a waiting entry to offered for ServerId, false when it is not waiting anymore
+func OfferWaitlist(Id string, ServerId string, ExpiresAt time.Time) bool {
+	result, err := DB.Exec("UPDATE sandbox_waitlist set status = ?, server_id = ?, offer_expires_at = ? where id = ? and status = ?", WaitlistOffered, ServerId, ExpiresAt.Unix(), Id, WaitlistWaiting)
+	if err != nil {
+		log.Print(err.Error())
+		return false
+	}
+	affected, err := result.RowsAffected()
+	return err == nil && affected > 0
+}
+
+// SetWaitlistOfferMessage keep the direct message of the offer so it can be updated once answered
+func SetWaitlistOfferMessage(Id string, Channel string, Ts string) {
+	_, err := DB.Exec("UPDATE sandbox_waitlist set offer_channel = ?, offer_ts = ? where id = ?", Channel, Ts, Id)
+	if err != nil {
+		log.Print(err.Error())
+	}
+}
+
+// CloseWaitlistOffer move an offered entry to To, the compare and set make sure an offer is answered once.
+// An entry put back to waiting stay in the queue
+func CloseWaitlistOffer(Id string, To string) bool {
+	closedAt := time.Now().Unix()
+	if To == WaitlistWaiting {
+		closedAt = 0
+	}
+	result, err := DB.Exec("UPDATE sandbox_waitlist set status = ?, closed_at = ? where id = ? and status = ?", To, closedAt, Id, WaitlistOffered)
+	if err != nil {
+		log.Print(err.Error())
+		return false
+	}
+	affected, err := result.RowsAffected()
+	return err == nil && affected > 0
+}
+
+// GetWaitlistHistory return the entries of the project that were in the queue between From and To
+func GetWaitlistHistory(Project string, From time.Time, To time.Time) []SandboxWaitlist {
+	return queryWaitlist("SELECT "+sandboxWaitlistColumns+" FROM sandbox_waitlist WHERE project = ? AND created_at < ? AND (closed_at > ? OR (closed_at = 0 AND status IN (?, ?))) order by created_at", strings.ToLower(Project), To.Unix(), From.Unix(), WaitlistWaiting, WaitlistOffered)
+}
+
+func queryWaitlist(Query string, Args ...any) []SandboxWaitlist {
+	results, err := DB.Query(Query, Args...)
+	if err != nil {
+		log.Print(err.Error())
+		return nil
+	}
+	defer results.Close()
+
+	var entries []SandboxWaitlist
+	for results.Next() {
+		var sandboxWaitlist SandboxWaitlist
+
+		err = results.Scan(sandboxWaitlist.scanFields()...)
+		if err != nil {
+			log.Print(err.Error())
+			continue
+		}
+		entries = append(entries, sandboxWaitlist)
+	}
+
+	return entries
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SlackGroupBinding give the members of a Slack user group a role on a project in an environment
+type SlackGroupBinding struct {
+	Id          string `json:"id"`
+	GroupId     string `json:"group_id"`
+	GroupHandle string `json:"group_handle"`
+	Role        string `json:"role"`
+	Project     string `json:"project"`
+	Environment string `json:"environment"`
+	CreatedBy   string `json:"created_by"`
+	CreatedAt   int    `json:"created_at"`
+	// SyncedAt and MemberCount are from the last successful sync
+	SyncedAt    int `json:"synced_at"`
+	MemberCount int `json:"member_count"`
+}
+
+const slackGroupBindingColumns = "id, group_id, group_handle, role, project, environment, created_by, created_at, synced_at, member_count"
+
+func (b *SlackGroupBinding) scanFields() []any {
+	return []any{&b.Id, &b.GroupId, &b.GroupHandle, &b.Role, &b.Project, &b.Environment, &b.CreatedBy, &b.CreatedAt, &b.SyncedAt, &b.MemberCount}
+}
+
+// AddGroupBinding bind the group to Role on Project in Environment, the role of an existing binding of the same scope is replaced
+func AddGroupBinding(GroupId string, GroupHandle string, Role string, Project string, Environment string, CreatedBy string) bool {
+	_, err := DB.Exec("INSERT INTO slack_group_binding ("+slackGroupBindingColumns+") values (?,?,?,?,?,?,?,?,?,?) ON DUPLICATE KEY UPDATE group_handle = VALUES(group_handle), role = VALUES(role)", uuid.New().String(), strings.ToUpper(GroupId), GroupHandle, Role, strings.ToLower(Project), strings.ToLower(Environment), CreatedBy, time.Now().Unix(), 0, 0)
+	if err != nil {
+		log.Print(err.Error())
+		return false
+	}
+	return true
+}
+
+// DeleteGroupBinding remove a binding and the grants synced from it, false when there is none
+func DeleteGroupBinding(GroupId string, Project string, Environment string) bool {
+	var slackGroupBinding SlackGroupBinding
+
+	err := DB.QueryRow("Select id from slack_group_binding where group_id = ? and project = ? and environment = ?", strings.ToUpper(GroupId), strings.ToLower(Project), strings.ToLower(Environment)).Scan(&slackGroupBinding.Id)
+	if err != nil {
+		return false
+	}
+
+	if _, err := DB.Exec("DELETE from slack_group_binding where id = ?", slackGroupBinding.Id); err != nil {
+		log.Print(err.Error())
+		return false
+	}
+	if _, err := DB.Exec("DELETE from slack_user_grant where source = ?", slackGroupBinding.Id); err != nil {
+		log.Print(err.Error())
+	}
+	return true
+}
+
+// GetGroupBindings return every binding
+func GetGroupBindings() []SlackGroupBinding {
+	results, err := DB.Query("SELECT " + slackGroupBindingColumns + " FROM slack_group_binding order by group_handle, project, environment")
+	if err != nil {
+		log.Print(err.Error())
+		return nil
+	}
+	defer results.Close()
+
+	var bindings []SlackGroupBinding
+	for results.Next() {
+		var slackGroupBinding SlackGroupBinding
+
+		err = results.Scan(slackGroupBinding.scanFields()...)
+		if err != nil {
+			log.Print(err.Error())
+			continue
+		}
+		bindings = append(bindings, slackGroupBinding)
+	}
+
+	return bindings
+}
+
+// SetGroupBindingSynced record a successful sync of the binding
+func SetGroupBindingSynced(Id string, MemberCount int) {
+	_, err := DB.Exec("UPDATE slack_group_binding set synced_at = ?, member_count = ? where id = ?", time.Now().Unix(), MemberCount, Id)
+	if err != nil {
+		log.Print(err.Error())
+	}
+}
+
+// GetGroupBindingList list the bindings with the time in the viewer Location
+func GetGroupBindingList(Location *time.Location) string {
+	tempList := ""
+	for i, binding := range GetGroupBindings() {
+		synced := "never synced"
+		if binding.SyncedAt > 0 {
+			synced = fmt.Sprintf("%d members synced %s", binding.MemberCount, FormatTime(binding.SyncedAt, Location))
+		}
+		tempList += fmt.Sprintf("%d. <!subteam^%s> is *%s* on project %s in %s, %s \n", i+1, binding.GroupId, binding.Role, binding.Project, binding.Environment, synced)
+	}
+	return tempList
+}
@@ -16,28 +16,57 @@ type SlackUserAccess struct {
 	Status   bool   `json:"status"`
 	AddedAt  int    `json:"added_at"`
 	FullName string `json:"full_name"`
-	Roles    int    `json:"roles"`
+	// Roles is the legacy 0 user, 1 admin, 2 sandbox only, 3 viewer, Role replace it
+	Roles int    `json:"roles"`
+	Role  string `json:"role"`
 }
 
+// Named roles, each one can do what the previous one can
+const (
+	RoleViewer   = "viewer"
+	RoleSandbox  = "sandbox"
+	RoleReleaser = "releaser"
+	RoleAdmin    = "admin"
+)
+
+// Roles is the named roles from the lowest to the highest
+var Roles = []string{RoleViewer, RoleSandbox, RoleReleaser, RoleAdmin}
+
+// RoleRank order the roles, 0 is an unknown role
+func RoleRank(Role string) int {
+	for i, role := range Roles {
+		if strings.EqualFold(role, Role) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// legacyRoles keep the roles int in sync for an older bot reading the table, a viewer is 3 which is none
+// of the old values so the older bot does not list them as a user nor let them use the sandbox or admin commands
+var legacyRoles = map[string]int{RoleViewer: 3, RoleSandbox: 2, RoleReleaser: 0, RoleAdmin: 1}
+
 func AddNewUser(SlackId string, FullName string) {
-	_, err := DB.Query("INSERT INTO slack_user_access values (?,?,?,?,?,?)", uuid.New(), strings.ToUpper(SlackId), true, time.Now().Unix(), FullName, 0)
+	_, err := DB.Exec("INSERT INTO slack_user_access (id, slack_id, status, added_at, full_name, roles, role) values (?,?,?,?,?,?,?)", uuid.New(), strings.ToUpper(SlackId), true, time.Now().Unix(), FullName, legacyRoles[RoleReleaser], RoleReleaser)
 	if err != nil {
 		log.Print(err.Error())
 	}
 }
 
 func GetAllUsers() string {
-	results, err := DB.Query("SELECT slack_id, status, full_name FROM slack_user_access where roles = 0 order by added_at;")
+	results, err := DB.Query("SELECT slack_id, status, full_name, role FROM slack_user_access order by added_at;")
 	if err != nil {
 		log.Print(err.Error())
+		return ""
 	}
+	defer results.Close()
 
 	tempList := ""
 	i := 1
 	for results.Next() {
 		var slackUserAccess SlackUserAccess
 
-		err = results.Scan(&slackUserAccess.SlackId, &slackUserAccess.Status, &slackUserAccess.FullName)
+		err = results.Scan(&slackUserAccess.SlackId, &slackUserAccess.Status, &slackUserAccess.FullName, &slackUserAccess.Role)
 
 		if err != nil {
 			log.Print(err.Error())
@@ -50,7 +79,7 @@ func GetAllUsers() string {
 		if !slackUserAccess.Status {
 			tempStatus = "Disabled"
 		}
-		tempList += fmt.Sprintf("%s. *%s* : %s (%s) \n\n", strconv.Itoa(i), slackUserAccess.FullName, slackUserAccess.SlackId, tempStatus)
+		tempList += fmt.Sprintf("%s. *%s* : %s, %s (%s) \n\n", strconv.Itoa(i), slackUserAccess.FullName, slackUserAccess.SlackId, slackUserAccess.Role, tempStatus)
 		i++
 	}
 
@@ -60,7 +89,7 @@ func GetAllUsers() string {
 func GetUserName(SlackId string) string {
 	var slackUserAccess SlackUserAccess
 
-	err := DB.QueryRow("Select full_name from slack_user_access where slack_id = ? and released = 0", SlackId).Scan(&slackUserAccess.FullName)
+	err := DB.QueryRow("Select full_name from slack_user_access where slack_id = ?", strings.ToUpper(SlackId)).Scan(&slackUserAccess.FullName)
 
 	if err != nil {
 		log.Print(err.Error())
@@ -77,33 +106,44 @@ func DeleteUserAccess(SlackId string) {
 	}
 }
 
-func ToogleUserStatus(SlackId string, UserStatus bool) {
-	_, err := DB.Query("UPDATE slack_user_access set status = ? where slack_id = ?", UserStatus, strings.ToUpper(SlackId))
+// ToogleUserStatus enable or disable an access list user, false when the user is not in the list
+func ToogleUserStatus(SlackId string, UserStatus bool) bool {
+	result, err := DB.Exec("UPDATE slack_user_access set status = ? where slack_id = ?", UserStatus, strings.ToUpper(SlackId))
 	if err != nil {
 		log.Print(err.Error())
+		return false
 	}
+	affected, err := result.RowsAffected()
+	return err == nil && affected > 0
 }
 
-func UserIsAdmin(SlackId string) bool {
-	var slackUserAccess SlackUserAccess
-
-	err := DB.QueryRow("Select id from slack_user_access where slack_id = ? and status = 1 and roles = 1", strings.ToUpper(SlackId)).Scan(&slackUserAccess.Id)
-
-	return err == nil
+// AddDisabledUser put a user that is not in the access list in it as a disabled viewer, so the roles
+// a user group give them do not apply until they are enabled or deleted
+func AddDisabledUser(SlackId string, FullName string) bool {
+	_, err := DB.Exec("INSERT INTO slack_user_access (id, slack_id, status, added_at, full_name, roles, role) values (?,?,?,?,?,?,?)", uuid.New(), strings.ToUpper(SlackId), false, time.Now().Unix(), FullName, legacyRoles[RoleViewer], RoleViewer)
+	if err != nil {
+		log.Print(err.Error())
+		return false
+	}
+	return true
 }
 
-func UserHasAccess(SlackId string) bool {
+// GetUserAccess return the access list row of a user, false when the user is not in the list
+func GetUserAccess(SlackId string) (SlackUserAccess, bool) {
 	var slackUserAccess SlackUserAccess
 
-	err := DB.QueryRow("Select id from slack_user_access where slack_id = ? and status = 1", strings.ToUpper(SlackId)).Scan(&slackUserAccess.Id)
+	err := DB.QueryRow("Select id, slack_id, status, added_at, full_name, roles, role from slack_user_access where slack_id = ?", strings.ToUpper(SlackId)).Scan(&slackUserAccess.Id, &slackUserAccess.SlackId, &slackUserAccess.Status, &slackUserAccess.AddedAt, &slackUserAccess.FullName, &slackUserAccess.Roles, &slackUserAccess.Role)
 
-	return err == nil
+	return slackUserAccess, err == nil
 }
 
-func UserHasAccessServerStatus(SlackId string) bool {
-	var slackUserAccess SlackUserAccess
-
-	err := DB.QueryRow("Select id from slack_user_access where slack_id = ? and status = 1 AND (roles = 1 OR roles = 2)", strings.ToUpper(SlackId)).Scan(&slackUserAccess.Id)
-
-	return err == nil
+// SetUserRole change the role of an access list user, false when the user is not in the list
+func SetUserRole(SlackId string, Role string) bool {
+	result, err := DB.Exec("UPDATE slack_user_access set role = ?, roles = ? where slack_id = ?", Role, legacyRoles[Role], strings.ToUpper(SlackId))
+	if err != nil {
+		log.Print(err.Error())
+		return false
+	}
+	affected, err := result.RowsAffected()
+	return err == nil && affected > 0
 }
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AnyScope is the project or environment of a grant that match all of them
+const AnyScope = "*"
+
+// SlackUserGrant give a user a role on a project in an environment, on top of the access list role.
+// Source is the group binding the grant was synced from, empty when it was given by hand
+type SlackUserGrant struct {
+	Id          string `json:"id"`
+	SlackId     string `json:"slack_id"`
+	Role        string `json:"role"`
+	Project     string `json:"project"`
+	Environment string `json:"environment"`
+	GrantedBy   string `json:"granted_by"`
+	GrantedAt   int    `json:"granted_at"`
+	Source      string `json:"source"`
+}
+
+const slackUserGrantColumns = "id, slack_id, role, project, environment, granted_by, granted_at, source"
+
+func (g *SlackUserGrant) scanFields() []any {
+	return []any{&g.Id, &g.SlackId, &g.Role, &g.Project, &g.Environment, &g.GrantedBy, &g.GrantedAt, &g.Source}
+}
+
+// SetGrant give SlackId the Role on Project in Environment by hand, an existing grant of the same scope is
+// replaced, a grant synced from a group included
+func SetGrant(SlackId string, Role string, Project string, Environment string, GrantedBy string) bool {
+	_, err := DB.Exec("INSERT INTO slack_user_grant ("+slackUserGrantColumns+") values (?,?,?,?,?,?,?,?) ON DUPLICATE KEY UPDATE role = VALUES(role), granted_by = VALUES(granted_by), granted_at = VALUES(granted_at), source = VALUES(source)", uuid.New().String(), strings.ToUpper(SlackId), Role, strings.ToLower(Project), strings.ToLower(Environment), GrantedBy, time.Now().Unix(), "")
+	if err != nil {
+		log.Print(err.Error())
+		return false
+	}
+	return true
+}
+
+// SetGroupGrant give SlackId the Role synced from the group binding Source, a grant given by hand on the same scope is kept
+func SetGroupGrant(SlackId string, Role string, Project string, Environment string, GrantedBy string, Source string) {
+	// the assignments run in order, source must be the last one so the others still see the old source
+	_, err := DB.Exec("INSERT INTO slack_user_grant ("+slackUserGrantColumns+") values (?,?,?,?,?,?,?,?) ON DUPLICATE KEY UPDATE role = IF(source = '', role, VALUES(role)), granted_by = IF(source = '', granted_by, VALUES(granted_by)), granted_at = IF(source = '', granted_at, VALUES(granted_at)), source = IF(source = '', source, VALUES(source))", uuid.New().String(), strings.ToUpper(SlackId), Role, strings.ToLower(Project), strings.ToLower(Environment), GrantedBy, time.Now().Unix(), Source)
+	if err != nil {
+		log.Print(err.Error())
+	}
+}
+
+// DeleteGrant remove the grant given by hand to SlackId on Project in Environment, false when there is none.
+// A grant synced from a group is removed by the sync once the user leave the group
+func DeleteGrant(SlackId string, Project string, Environment string) bool {
+	result, err := DB.Exec("DELETE from slack_user_grant where slack_id = ? and project = ? and environment = ? and source = ''", strings.ToUpper(SlackId), strings.ToLower(Project), strings.ToLower(Environment))
+	if err != nil {
+		log.Print(err.Error())
+		return false
+	}
+	affected, err := result.RowsAffected()
+	return err == nil && affected > 0
+}
+
+// GetUserGrants return the grants of SlackId in Environment, a grant on every environment included
+func GetUserGrants(SlackId string, Environment string) []SlackUserGrant {
+	return queryGrants("SELECT "+slackUserGrantColumns+" FROM slack_user_grant WHERE slack_id = ? AND environment IN (?, ?)", strings.ToUpper(SlackId), strings.ToLower(Environment), AnyScope)
+}
+
+// DeleteGrantById remove a single grant
+func DeleteGrantById(Id string) {
+	_, err := DB.Exec("DELETE from slack_user_grant where id = ?", Id)
+	if err != nil {
+		log.Print(err.Error())
+	}
+}
+
+// GetGrants return every grant, the sync compare them with the group members
+func GetGrants() []SlackUserGrant {
+	return queryGrants("SELECT " + slackUserGrantColumns + " FROM slack_user_grant")
+}
+
+// GetAllGrants list the grants, of SlackId only when it is not empty
+func GetAllGrants(SlackId string) string {
+	query := "SELECT " + slackUserGrantColumns + " FROM slack_user_grant"
+	var args []any
+	if SlackId != "" {
+		query += " WHERE slack_id = ?"
+		args = append(args, strings.ToUpper(SlackId))
+	}
+
+	tempList := ""
+	for i, grant := range queryGrants(query+" order by slack_id, project, environment", args...) {
+		origin := "by hand"
+		if grant.Source != "" {
+			origin = "from a user group"
+		}
+		tempList += fmt.Sprintf("%d. <@%s> is *%s* on project %s in %s, granted by %s %s \n", i+1, grant.SlackId, grant.Role, grant.Project, grant.Environment, grant.GrantedBy, origin)
+	}
+	return tempList
+}
+
+func queryGrants(Query string, Args ...any) []SlackUserGrant {
+	results, err := DB.Query(Query, Args...)
+	if err != nil {
+		log.Print(err.Error())
+		return nil
+	}
+	defer results.Close()
+
+	var grants []SlackUserGrant
+	for results.Next() {
+		var slackUserGrant SlackUserGrant
+
+		err = results.Scan(slackUserGrant.scanFields()...)
+		if err != nil {
+			log.Print(err.Error())
+			continue
+		}
+		grants = append(grants, slackUserGrant)
+	}
+
+	return grants
+}
@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type TestingStatus struct {
@@ -18,49 +21,364 @@ type TestingStatus struct {
 	StatusChangedOn int    `json:"status_changed_on"`
 	LastFeBranch    string `json:"last_fe_branch"`
 	LastBeBranch    string `json:"last_be_branch"`
+	// ClaimedById is the slack id holding the server, empty when it was not claimed from the bot
+	ClaimedById string `json:"claimed_by_id"`
+	ClaimReason string `json:"claim_reason"`
+	ClaimUntil  int    `json:"claim_until"`
+	// Enabled is false for a server taken out of rotation, URL, Description and OwnerTeam are informative
+	Enabled     bool   `json:"enabled"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+	OwnerTeam   string `json:"owner_team"`
+	// HealthURL is probed by the bot when set, the Health fields keep the last result
+	HealthURL       string `json:"health_url"`
+	HealthUp        bool   `json:"health_up"`
+	HealthMs        int    `json:"health_ms"`
+	HealthVersion   string `json:"health_version"`
+	HealthError     string `json:"health_error"`
+	HealthCheckedAt int    `json:"health_checked_at"`
+}
+
+// SandboxServerInfo is the metadata an admin set on a sandbox server
+type SandboxServerInfo struct {
+	URL         string
+	Description string
+	OwnerTeam   string
+	HealthURL   string
+}
+
+// SandboxHealth is the result of a probe of the health url
+type SandboxHealth struct {
+	Up      bool
+	Ms      int
+	Version string
+	Error   string
 }
 
-func GetServerStatus(Project string) string {
-	results, err := DB.Query("SELECT server_id, last_build_by, last_build_on, status, status_changed_by, status_changed_on, last_fe_branch, last_be_branch FROM testing_status where project = ? order by server_id;", Project)
+const testingStatusColumns = "project, server_id, last_build_by, last_build_on, status, status_changed_by, status_changed_on, last_fe_branch, last_be_branch, claimed_by_id, claim_reason, claim_until, enabled, url, description, owner_team, health_url, health_up, health_ms, health_version, health_error, health_checked_at"
+
+func (t *TestingStatus) scanFields() []any {
+	return []any{&t.Project, &t.ServerId, &t.LastBuildBy, &t.LastBuildOn, &t.Status, &t.StatusChangedBy, &t.StatusChangedOn, &t.LastFeBranch, &t.LastBeBranch, &t.ClaimedById, &t.ClaimReason, &t.ClaimUntil, &t.Enabled, &t.URL, &t.Description, &t.OwnerTeam, &t.HealthURL, &t.HealthUp, &t.HealthMs, &t.HealthVersion, &t.HealthError, &t.HealthCheckedAt}
+}
+
+// GetServerStatus list the sandbox servers of a project with the time in the viewer Location
+func GetServerStatus(Project string, Location *time.Location) string {
+	results, err := DB.Query("SELECT "+testingStatusColumns+" FROM testing_status where project = ? order by server_id;", Project)
 	if err != nil {
 		log.Print(err.Error())
+		return ""
 	}
+	defer results.Close()
 
 	tempList := ""
 	i := 1
 	for results.Next() {
 		var testingStatus TestingStatus
 
-		err = results.Scan(&testingStatus.ServerId, &testingStatus.LastBuildBy, &testingStatus.LastBuildOn, &testingStatus.Status, &testingStatus.StatusChangedBy, &testingStatus.StatusChangedOn, &testingStatus.LastFeBranch, &testingStatus.LastBeBranch)
+		err = results.Scan(testingStatus.scanFields()...)
 
 		if err != nil {
 			log.Print(err.Error())
 		}
 
-		temp, _ := strconv.ParseInt(strconv.Itoa(testingStatus.LastBuildOn), 10, 64)
-		// temp2, _ := strconv.ParseInt(strconv.Itoa(testingStatus.StatusChangedOn), 10, 64)
-		location, _ := time.LoadLocation("Asia/Jakarta")
-		tempDate := time.Unix(temp, 0).In(location)
-
-		// tempDate2 := time.Unix(temp2, 0).In(location)
+		tempDate := FormatTime(testingStatus.LastBuildOn, Location)
+		tempDate2 := FormatTime(testingStatus.StatusChangedOn, Location)
 
-		// tempStatus := "In use"
-		// if !testingStatus.Status {
-		// 	tempStatus = "Not in use"
-		// }
+		tempStatus := "Not in use"
+		tempClaim := ""
+		if !testingStatus.Enabled {
+			tempStatus = "Disabled"
+		} else if testingStatus.Status {
+			tempStatus = "In use"
+			tempClaim = fmt.Sprintf(" In use by: *%s* since %s \n", testingStatus.StatusChangedBy, tempDate2)
+			if testingStatus.ClaimUntil > 0 {
+				tempClaim += fmt.Sprintf(" Expected until: %s \n", FormatTime(testingStatus.ClaimUntil, Location))
+			}
+			if testingStatus.ClaimReason != "" {
+				tempClaim += fmt.Sprintf(" Reason: %s \n", testingStatus.ClaimReason)
+			}
+		} else if testingStatus.StatusChangedBy != "" {
+			tempClaim = fmt.Sprintf(" Last set done by: %s (%s) \n", testingStatus.StatusChangedBy, tempDate2)
+		}
 
-		// tempList += fmt.Sprintf("%s. Server Id: *%s* (%s) \n Last build by: *%s* (%s) \n Last set done by: %s (%s) \n\n", strconv.Itoa(i), testingStatus.ServerId, tempStatus, testingStatus.LastBuildBy, tempDate, testingStatus.StatusChangedBy, tempDate2)
+		tempInfo := ""
+		if testingStatus.Description != "" {
+			tempInfo += fmt.Sprintf(" %s \n", testingStatus.Description)
+		}
+		if testingStatus.URL != "" {
+			tempInfo += fmt.Sprintf(" URL: %s \n", testingStatus.URL)
+		}
+		if testingStatus.OwnerTeam != "" {
+			tempInfo += fmt.Sprintf(" Owner team: %s \n", testingStatus.OwnerTeam)
+		}
+		if health := testingStatus.HealthText(Location); health != "" {
+			tempInfo += fmt.Sprintf(" Health: %s \n", health)
+		}
 
-		tempList += fmt.Sprintf("%s. Server Id: *%s* \n Last build by: *%s* (%s) \n Last FE Branch: %s \n Last BE Branch: %s \n\n", strconv.Itoa(i), testingStatus.ServerId, testingStatus.LastBuildBy, tempDate, testingStatus.LastFeBranch, testingStatus.LastBeBranch)
+		tempList += fmt.Sprintf("%s. Server Id: *%s* (%s) \n%s%s Last build by: *%s* (%s) \n Last FE Branch: %s \n Last BE Branch: %s \n\n", strconv.Itoa(i), testingStatus.ServerId, tempStatus, tempInfo, tempClaim, testingStatus.LastBuildBy, tempDate, testingStatus.LastFeBranch, testingStatus.LastBeBranch)
 		i++
 	}
 
 	return tempList
 }
 
+// HealthText describe the last probe, e.g. up (120ms, version 1.4.2) checked 10:05, empty without a health url
+func (t TestingStatus) HealthText(Location *time.Location) string {
+	if t.HealthURL == "" {
+		return ""
+	}
+	if t.HealthCheckedAt == 0 {
+		return "not checked yet"
+	}
+
+	text := "down"
+	if t.HealthUp {
+		text = "up"
+	}
+	var details []string
+	if t.HealthUp || t.HealthMs > 0 {
+		details = append(details, fmt.Sprintf("%dms", t.HealthMs))
+	}
+	if t.HealthVersion != "" {
+		details = append(details, "version "+t.HealthVersion)
+	}
+	if !t.HealthUp && t.HealthError != "" {
+		details = append(details, t.HealthError)
+	}
+	if len(details) > 0 {
+		text += " (" + strings.Join(details, ", ") + ")"
+	}
+	return text + " checked " + FormatTime(t.HealthCheckedAt, Location)
+}
+
+// GetServer return a single sandbox server
+func GetServer(Project string, ServerId string) (TestingStatus, bool) {
+	var testingStatus TestingStatus
+
+	err := DB.QueryRow("SELECT "+testingStatusColumns+" FROM testing_status where project = ? and server_id = ?", Project, ServerId).Scan(testingStatus.scanFields()...)
+
+	return testingStatus, err == nil
+}
+
 func UpdateServerStatus(Project string, ServerId string, Name string) {
-	_, err := DB.Query("UPDATE testing_status set status = 0, status_changed_by = ?, status_changed_on = ? where project = ? and server_id = ?", Name, time.Now().Unix(), Project, ServerId)
+	_, err := DB.Exec("UPDATE testing_status set status = 0, status_changed_by = ?, status_changed_on = ?, claimed_by_id = '', claim_reason = '', claim_until = 0, claim_reminded_at = 0 where project = ? and server_id = ?", Name, time.Now().Unix(), Project, ServerId)
+	if err != nil {
+		log.Print(err.Error())
+		return
+	}
+	closeClaimHistory(Project, ServerId, Name)
+}
+
+// ClaimServer mark a sandbox server as in use by SlackId until Until, the claim only succeed when the server
+// is free or already held by the same user, which extend it. False means the server is missing or held by someone else.
+func ClaimServer(Project string, ServerId string, SlackId string, Name string, Reason string, Until time.Time) bool {
+	if !ReserveServer(Project, ServerId, SlackId, Name, Reason, Until) {
+		return false
+	}
+	openClaimHistory(Project, ServerId, SlackId, Name, Reason)
+	return true
+}
+
+// ReserveServer hold a sandbox server for SlackId like ClaimServer but without starting the claim history,
+// e.g. while a waitlist offer wait for an answer, the history start when the reservation is claimed
+func ReserveServer(Project string, ServerId string, SlackId string, Name string, Reason string, Until time.Time) bool {
+	result, err := DB.Exec("UPDATE testing_status set status = 1, status_changed_by = ?, status_changed_on = ?, claimed_by_id = ?, claim_reason = ?, claim_until = ?, claim_reminded_at = 0 where project = ? and server_id = ? and enabled = 1 and (status = 0 or claimed_by_id = ?)", Name, time.Now().Unix(), SlackId, Reason, Until.Unix(), Project, ServerId, SlackId)
+	if err != nil {
+		log.Print(err.Error())
+		return false
+	}
+	affected, err := result.RowsAffected()
+	return err == nil && affected > 0
+}
+
+// GetSandboxProjects return the projects that have at least one sandbox server
+func GetSandboxProjects() []string {
+	results, err := DB.Query("SELECT DISTINCT project FROM testing_status order by project")
+	if err != nil {
+		log.Print(err.Error())
+		return nil
+	}
+	defer results.Close()
+
+	var projects []string
+	for results.Next() {
+		var testingStatus TestingStatus
+		if err := results.Scan(&testingStatus.Project); err != nil {
+			log.Print(err.Error())
+			continue
+		}
+		projects = append(projects, testingStatus.Project)
+	}
+	return projects
+}
+
+// GetAllServers return every sandbox server of every project
+func GetAllServers() []TestingStatus {
+	return queryServers("SELECT " + testingStatusColumns + " FROM testing_status order by project, server_id")
+}
+
+// GetFreeServer return a sandbox server of the project that is not in use
+func GetFreeServer(Project string) (string, bool) {
+	var testingStatus TestingStatus
+
+	err := DB.QueryRow("Select server_id from testing_status where project = ? and status = 0 and enabled = 1 order by server_id limit 1", Project).Scan(&testingStatus.ServerId)
+
+	return testingStatus.ServerId, err == nil
+}
+
+// HasSandboxServer is true when the project has at least one enabled sandbox server
+func HasSandboxServer(Project string) bool {
+	var testingStatus TestingStatus
+
+	err := DB.QueryRow("Select server_id from testing_status where project = ? and enabled = 1 limit 1", Project).Scan(&testingStatus.ServerId)
+
+	return err == nil
+}
+
+// GetClaimsToRemind return the claims made from the bot that expire before Before and were not reminded yet
+func GetClaimsToRemind(Before time.Time) []TestingStatus {
+	return queryServers("SELECT "+testingStatusColumns+" FROM testing_status WHERE status = 1 AND claimed_by_id != '' AND claim_until > 0 AND claim_until <= ? AND claim_reminded_at = 0", Before.Unix())
+}
+
+// GetExpiredClaims return the claims made from the bot that expired at Now
+func GetExpiredClaims(Now time.Time) []TestingStatus {
+	return queryServers("SELECT "+testingStatusColumns+" FROM testing_status WHERE status = 1 AND claimed_by_id != '' AND claim_until > 0 AND claim_until <= ?", Now.Unix())
+}
+
+// SetClaimReminded record the holder was reminded of the claim expiry
+func SetClaimReminded(Project string, ServerId string) {
+	_, err := DB.Exec("UPDATE testing_status set claim_reminded_at = ? where project = ? and server_id = ?", time.Now().Unix(), Project, ServerId)
+	if err != nil {
+		log.Print(err.Error())
+	}
+}
+
+// ExtendClaim move the claim expiry of SlackId to Until, false when SlackId does not hold the server anymore
+func ExtendClaim(Project string, ServerId string, SlackId string, Until time.Time) bool {
+	result, err := DB.Exec("UPDATE testing_status set claim_until = ?, claim_reminded_at = 0 where project = ? and server_id = ? and status = 1 and claimed_by_id = ?", Until.Unix(), Project, ServerId, SlackId)
+	if err != nil {
+		log.Print(err.Error())
+		return false
+	}
+	affected, err := result.RowsAffected()
+	return err == nil && affected > 0
+}
+
+// ExpireClaim free a server whose claim still end at ClaimUntil, so a claim extended meanwhile is kept
+func ExpireClaim(Project string, ServerId string, ClaimUntil int, Name string) bool {
+	result, err := DB.Exec("UPDATE testing_status set status = 0, status_changed_by = ?, status_changed_on = ?, claimed_by_id = '', claim_reason = '', claim_until = 0, claim_reminded_at = 0 where project = ? and server_id = ? and status = 1 and claim_until = ?", Name, time.Now().Unix(), Project, ServerId, ClaimUntil)
+	if err != nil {
+		log.Print(err.Error())
+		return false
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false
+	}
+	closeClaimHistory(Project, ServerId, Name)
+	return true
+}
+
+func queryServers(Query string, Args ...any) []TestingStatus {
+	results, err := DB.Query(Query, Args...)
+	if err != nil {
+		log.Print(err.Error())
+		return nil
+	}
+	defer results.Close()
+
+	var servers []TestingStatus
+	for results.Next() {
+		var testingStatus TestingStatus
+
+		err = results.Scan(testingStatus.scanFields()...)
+		if err != nil {
+			log.Print(err.Error())
+			continue
+		}
+		servers = append(servers, testingStatus)
+	}
+
+	return servers
+}
+
+// UpdateServerBuild record a sandbox build, false when the server does not exist
+func UpdateServerBuild(Project string, ServerId string, BuildBy string, FeBranch string, BeBranch string) bool {
+	result, err := DB.Exec("UPDATE testing_status set last_build_by = ?, last_build_on = ?, last_fe_branch = ?, last_be_branch = ? where project = ? and server_id = ?", BuildBy, time.Now().Unix(), FeBranch, BeBranch, Project, ServerId)
+	if err != nil {
+		log.Print(err.Error())
+		return false
+	}
+	affected, err := result.RowsAffected()
+	return err == nil && affected > 0
+}
+
+// AddServer create a free sandbox server, false when the project already has this server id
+func AddServer(Project string, ServerId string, Info SandboxServerInfo) bool {
+	_, err := DB.Exec("INSERT INTO testing_status (id, project, server_id, last_build_by, last_build_on, status, status_changed_by, status_changed_on, last_fe_branch, last_be_branch, enabled, url, description, owner_team, health_url) values (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)", uuid.New().String(), strings.ToLower(Project), ServerId, "", 0, false, "", 0, "", "", true, Info.URL, Info.Description, Info.OwnerTeam, Info.HealthURL)
+	if err != nil {
+		log.Print(err.Error())
+		return false
+	}
+	return true
+}
+
+// DeleteServer remove a sandbox server, false when it does not exist. Its history is kept for the reports
+func DeleteServer(Project string, ServerId string) bool {
+	if !execServer("DELETE from testing_status where project = ? and server_id = ?", strings.ToLower(Project), ServerId) {
+		return false
+	}
+	closeClaimHistory(strings.ToLower(Project), ServerId, BotActor)
+	return true
+}
+
+// RenameServer change the server id, an open waitlist offer and the claim history follow the server
+func RenameServer(Project string, ServerId string, NewServerId string) bool {
+	if !execServer("UPDATE testing_status set server_id = ? where project = ? and server_id = ?", NewServerId, strings.ToLower(Project), ServerId) {
+		return false
+	}
+	_, err := DB.Exec("UPDATE sandbox_waitlist set server_id = ? where project = ? and server_id = ? and status = ?", NewServerId, strings.ToLower(Project), ServerId, WaitlistOffered)
+	if err != nil {
+		log.Print(err.Error())
+	}
+	_, err = DB.Exec("UPDATE sandbox_claim_history set server_id = ? where project = ? and server_id = ?", NewServerId, strings.ToLower(Project), ServerId)
+	if err != nil {
+		log.Print(err.Error())
+	}
+	return true
+}
+
+// ToggleServer enable or disable a sandbox server, false when it does not exist
+func ToggleServer(Project string, ServerId string, Enabled bool) bool {
+	return execServer("UPDATE testing_status set enabled = ? where project = ? and server_id = ?", Enabled, strings.ToLower(Project), ServerId)
+}
+
+// SetServerInfo replace the metadata of a sandbox server, false when it does not exist.
+// The last probe is forgotten when the health url change
+func SetServerInfo(Project string, ServerId string, Info SandboxServerInfo) bool {
+	return execServer("UPDATE testing_status set url = ?, description = ?, owner_team = ?, health_checked_at = if(health_url = ?, health_checked_at, 0), health_url = ? where project = ? and server_id = ?", Info.URL, Info.Description, Info.OwnerTeam, Info.HealthURL, Info.HealthURL, strings.ToLower(Project), ServerId)
+}
+
+// GetServersToProbe return the enabled sandbox servers with a health url
+func GetServersToProbe() []TestingStatus {
+	return queryServers("SELECT " + testingStatusColumns + " FROM testing_status WHERE enabled = 1 AND health_url != '' order by project, server_id")
+}
+
+// SetServerHealth keep the result of the last probe of HealthURL, a probe of a health url changed meanwhile is dropped
+func SetServerHealth(Project string, ServerId string, HealthURL string, Health SandboxHealth) {
+	_, err := DB.Exec("UPDATE testing_status set health_up = ?, health_ms = ?, health_version = ?, health_error = ?, health_checked_at = ? where project = ? and server_id = ? and health_url = ?", Health.Up, Health.Ms, Health.Version, Health.Error, time.Now().Unix(), Project, ServerId, HealthURL)
+	if err != nil {
+		log.Print(err.Error())
+	}
+}
+
+// execServer run an update on a single server and report if a row was found
+func execServer(Query string, Args ...any) bool {
+	result, err := DB.Exec(Query, Args...)
 	if err != nil {
 		log.Print(err.Error())
+		return false
 	}
+	affected, err := result.RowsAffected()
+	return err == nil && affected > 0
 }
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+type UserPreference struct {
+	SlackId   string `json:"slack_id"`
+	Timezone  string `json:"timezone"`
+	UpdatedAt int    `json:"updated_at"`
+}
+
+// GetUserTimezone return the timezone the user set, empty when they never set one
+func GetUserTimezone(SlackId string) string {
+	var userPreference UserPreference
+
+	err := DB.QueryRow("Select timezone from user_preference where slack_id = ?", strings.ToUpper(SlackId)).Scan(&userPreference.Timezone)
+
+	if err != nil {
+		return ""
+	}
+
+	return userPreference.Timezone
+}
+
+// SetUserTimezone save the user timezone, empty Timezone remove the preference
+func SetUserTimezone(SlackId string, Timezone string) {
+	var err error
+	if Timezone == "" {
+		_, err = DB.Exec("DELETE from user_preference where slack_id = ?", strings.ToUpper(SlackId))
+	} else {
+		_, err = DB.Exec("INSERT INTO user_preference (slack_id, timezone, updated_at) values (?,?,?) ON DUPLICATE KEY UPDATE timezone = VALUES(timezone), updated_at = VALUES(updated_at)", strings.ToUpper(SlackId), Timezone, time.Now().Unix())
+	}
+	if err != nil {
+		log.Print(err.Error())
+	}
+}
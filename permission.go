@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/config"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// Permission is the role a user needs to run a command
+type Permission int
+
+const (
+	// PermissionNone can be run by anyone in the workspace
+	PermissionNone Permission = iota
+	// PermissionSandbox requires the sandbox role, to claim and deploy sandbox servers
+	PermissionSandbox
+	// PermissionRelease requires the releaser role, to release and schedule
+	PermissionRelease
+	// PermissionAdmin requires the admin role
+	PermissionAdmin
+)
+
+// permissionRoles is the lowest role each permission accept
+var permissionRoles = map[Permission]string{
+	PermissionNone:    models.RoleViewer,
+	PermissionSandbox: models.RoleSandbox,
+	PermissionRelease: models.RoleReleaser,
+	PermissionAdmin:   models.RoleAdmin,
+}
+
+// defaultRole is the role of a user that is not in the access list, DEFAULT_ROLE default to viewer
+// so nobody can claim a sandbox server or release without being added, set it to sandbox to open
+// the sandbox servers to the whole workspace
+func defaultRole() string {
+	role := strings.ToLower(config.GetConfig("DEFAULT_ROLE"))
+	if models.RoleRank(role) == 0 {
+		return models.RoleViewer
+	}
+	return role
+}
+
+// currentEnvironment is the environment the bot release to, the grants are matched against it
+func currentEnvironment() string {
+	return strings.ToLower(config.GetConfig("ENVIRONMENT"))
+}
+
+// userRoles return the access list role, or the default role for someone not in the list, with the grants
+// of the current environment. A disabled user is only a viewer and lose the grants
+func userRoles(SlackId string) (string, []models.SlackUserGrant) {
+	access, ok := models.GetUserAccess(SlackId)
+	if ok && !access.Status {
+		return models.RoleViewer, nil
+	}
+	role := defaultRole()
+	if ok {
+		role = access.Role
+	}
+	return role, models.GetUserGrants(SlackId, currentEnvironment())
+}
+
+// projectRole is the highest of the user role and the grants matching the project, AnyScope match a grant on any project
+func projectRole(SlackId string, Project string) string {
+	role, grants := userRoles(SlackId)
+	return effectiveRole(role, grants, Project)
+}
+
+// effectiveRole raise role to the highest grant matching the project, a grant never lower the role
+func effectiveRole(role string, grants []models.SlackUserGrant, Project string) string {
+	for _, grant := range grants {
+		matches := Project == models.AnyScope || grant.Project == models.AnyScope || strings.EqualFold(grant.Project, Project)
+		if matches && models.RoleRank(grant.Role) > models.RoleRank(role) {
+			role = grant.Role
+		}
+	}
+	return role
+}
+
+// Allows check the user has the role on at least one project, it decide who see a command in help.
+// Admin commands manage the bot itself so only an admin of every project in every environment can run them
+func (p Permission) Allows(SlackId string) bool {
+	if p == PermissionNone {
+		return true
+	}
+	if p == PermissionAdmin {
+		role, grants := userRoles(SlackId)
+		for _, grant := range grants {
+			if grant.Role == models.RoleAdmin && grant.Project == models.AnyScope && grant.Environment == models.AnyScope {
+				return true
+			}
+		}
+		return role == models.RoleAdmin
+	}
+	return p.AllowsProject(SlackId, models.AnyScope)
+}
+
+// AllowsProject check the role of the user on the project in the environment the bot release to
+func (p Permission) AllowsProject(SlackId string, Project string) bool {
+	return models.RoleRank(projectRole(SlackId, Project)) >= models.RoleRank(permissionRoles[p])
+}
+
+// permissionDenied is the reply when the user lack the role, on the project when it is not empty
+func permissionDenied(req *CommandRequest, project string) slack.Attachment {
+	req.denied = true
+	text := fmt.Sprintf("Sorry <@%s>, you don't have the permission to do that 🙏", req.User.ID)
+	if project != "" {
+		text = fmt.Sprintf("Sorry <@%s>, you don't have the permission to do that on %s in %s 🙏", req.User.ID, project, currentEnvironment())
+	}
+	return slack.Attachment{
+		Text:   text,
+		Color:  "#e20228",
+		Footer: "GRIP Release Bot cannot continue",
+	}
+}
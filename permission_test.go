@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+func TestEffectiveRole(t *testing.T) {
+	grant := func(role string, project string) models.SlackUserGrant {
+		return models.SlackUserGrant{Role: role, Project: project, Environment: models.AnyScope}
+	}
+
+	tests := []struct {
+		name    string
+		role    string
+		grants  []models.SlackUserGrant
+		project string
+		want    string
+	}{
+		{"role without grant", models.RoleReleaser, nil, "grip", models.RoleReleaser},
+		{"scoped grant raise the role on its project", models.RoleViewer, []models.SlackUserGrant{grant(models.RoleAdmin, "grip")}, "grip", models.RoleAdmin},
+		{"scoped grant ignore other projects", models.RoleViewer, []models.SlackUserGrant{grant(models.RoleAdmin, "grip")}, "logistics", models.RoleViewer},
+		{"scoped grant match case insensitive", models.RoleViewer, []models.SlackUserGrant{grant(models.RoleReleaser, "GRIP")}, "grip", models.RoleReleaser},
+		{"any project grant match every project", models.RoleViewer, []models.SlackUserGrant{grant(models.RoleSandbox, models.AnyScope)}, "logistics", models.RoleSandbox},
+		{"grant never lower the role", models.RoleAdmin, []models.SlackUserGrant{grant(models.RoleViewer, "grip")}, "grip", models.RoleAdmin},
+		{"role above the any project grant", models.RoleReleaser, []models.SlackUserGrant{grant(models.RoleSandbox, models.AnyScope)}, "grip", models.RoleReleaser},
+		{"scoped grant above the any project grant", models.RoleViewer, []models.SlackUserGrant{grant(models.RoleSandbox, models.AnyScope), grant(models.RoleAdmin, "grip")}, "grip", models.RoleAdmin},
+		{"any project grant above the scoped grant", models.RoleViewer, []models.SlackUserGrant{grant(models.RoleAdmin, models.AnyScope), grant(models.RoleSandbox, "grip")}, "grip", models.RoleAdmin},
+		{"any project grant elsewhere", models.RoleViewer, []models.SlackUserGrant{grant(models.RoleSandbox, models.AnyScope), grant(models.RoleAdmin, "grip")}, "logistics", models.RoleSandbox},
+		{"asking for any project match a scoped grant", models.RoleViewer, []models.SlackUserGrant{grant(models.RoleReleaser, "grip")}, models.AnyScope, models.RoleReleaser},
+		{"unknown grant role is ignored", models.RoleSandbox, []models.SlackUserGrant{grant("owner", "grip")}, "grip", models.RoleSandbox},
+	}
+
+	for _, test := range tests {
+		if got := effectiveRole(test.role, test.grants, test.project); got != test.want {
+			t.Errorf("%s: effectiveRole(%s, %d grants, %s) = %s, want %s", test.name, test.role, len(test.grants), test.project, got, test.want)
+		}
+	}
+}
+
+func TestDefaultRole(t *testing.T) {
+	tests := []struct {
+		config string
+		want   string
+	}{
+		{"", models.RoleViewer},
+		{"sandbox", models.RoleSandbox},
+		{"Releaser", models.RoleReleaser},
+		{"owner", models.RoleViewer},
+	}
+
+	for _, test := range tests {
+		t.Setenv("DEFAULT_ROLE", test.config)
+		if got := defaultRole(); got != test.want {
+			t.Errorf("DEFAULT_ROLE=%q: defaultRole() = %s, want %s", test.config, got, test.want)
+		}
+		// a user that is not in the access list get the default role
+		if got := projectRole("U0NOBODY", "grip"); got != test.want {
+			t.Errorf("DEFAULT_ROLE=%q: projectRole of an unknown user = %s, want %s", test.config, got, test.want)
+		}
+	}
+}
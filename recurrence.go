@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// cronParser accept the standard 5 fields and the @daily style descriptors
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// recurrenceDays map the friendly day names to the cron day of week field
+var recurrenceDays = map[string]string{
+	"day":       "*",
+	"weekday":   "1-5",
+	"weekend":   "0,6",
+	"sunday":    "0",
+	"monday":    "1",
+	"tuesday":   "2",
+	"wednesday": "3",
+	"thursday":  "4",
+	"friday":    "5",
+	"saturday":  "6",
+}
+
+// ParseRecurrence turn "weekday at 18:00", "friday at 9pm" or a cron expression
+// like "0 18 * * 1-5" into the cron expression stored for the recurrence
+func ParseRecurrence(input string) (string, cron.Schedule, error) {
+	text := strings.Join(strings.Fields(input), " ")
+
+	if friendly, ok := friendlyRecurrence(strings.ToLower(text)); ok {
+		text = friendly
+	}
+
+	schedule, err := cronParser.Parse(text)
+	if err != nil {
+		return "", nil, fmt.Errorf("%q is not a recurrence I understand: %s", input, err.Error())
+	}
+	return text, schedule, nil
+}
+
+func friendlyRecurrence(text string) (string, bool) {
+	day, clock, found := strings.Cut(text, " at ")
+	if !found {
+		return "", false
+	}
+	dow, ok := recurrenceDays[strings.TrimSuffix(day, "s")]
+	if !ok {
+		return "", false
+	}
+	hour, minute, err := parseClock(clock)
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%d %d * * %s", minute, hour, dow), true
+}
+
+// NextRecurrenceRun is the first run of the recurrence after now in its own timezone
+func NextRecurrenceRun(recurrence models.ReleaseRecurrence, now time.Time) (time.Time, error) {
+	_, schedule, err := ParseRecurrence(recurrence.CronExpr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	location := loadLocation(recurrence.Timezone)
+	if location == nil {
+		location = DefaultLocation()
+	}
+	return schedule.Next(now.In(location)), nil
+}
+
+// runDueRecurrence create the release schedule for every due recurrence and move it to the next run,
+// runs missed while the bot was down are skipped instead of released one after another
+func runDueRecurrence(now time.Time) {
+	for _, recurrence := range models.GetDueRecurrence(now) {
+		next, err := NextRecurrenceRun(recurrence, now)
+		if err != nil {
+			log.Println("cannot schedule recurrence", recurrence.Id, err.Error())
+			models.SetRecurrenceStatus(recurrence.Id, models.RecurrencePaused, time.Unix(int64(recurrence.NextRunOn), 0))
+			continue
+		}
+
+		log.Println("OK Recurrence", recurrence.Id, recurrence.ReleaseProject, recurrence.ReleaseVersion)
+		if _, err := models.CreateRecurringSchedule(recurrence, time.Unix(int64(recurrence.NextRunOn), 0)); err != nil {
+			// the recurrence stay due so the next tick try again
+			log.Println("cannot create recurring release", recurrence.Id, err.Error())
+			continue
+		}
+		auditBot("run recurrence", fmt.Sprintf("%s %s <<%s>>", recurrence.Id, recurrence.ReleaseProject, recurrence.ReleaseVersion))
+		models.UpdateRecurrenceRun(recurrence.Id, now, next)
+	}
+}
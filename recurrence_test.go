@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		// friendly forms
+		{input: "weekday at 18:00", want: "0 18 * * 1-5"},
+		{input: "weekdays at 6pm", want: "0 18 * * 1-5"},
+		{input: "Friday at 9:30PM", want: "30 21 * * 5"},
+		{input: "fridays  at  09:00", want: "0 9 * * 5"},
+		{input: "day at 09:00", want: "0 9 * * *"},
+		{input: "days at 7 AM", want: "0 7 * * *"},
+		{input: "weekend at 10am", want: "0 10 * * 0,6"},
+		{input: "sunday at 00:00", want: "0 0 * * 0"},
+
+		// cron expressions are kept, with the spaces normalized
+		{input: "0 18 * * 1-5", want: "0 18 * * 1-5"},
+		{input: " 30  9 1 * * ", want: "30 9 1 * *"},
+		{input: "@daily", want: "@daily"},
+
+		// invalid
+		{input: "", wantErr: true},
+		{input: "someday at 18:00", wantErr: true},
+		{input: "friday at 25:00", wantErr: true},
+		{input: "fri at 18:00", wantErr: true},
+		{input: "61 18 * * *", wantErr: true},
+		{input: "0 18 * *", wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, schedule, err := ParseRecurrence(test.input)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ParseRecurrence(%q) = %q, want an error", test.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseRecurrence(%q) error: %s", test.input, err.Error())
+			continue
+		}
+		if got != test.want || schedule == nil {
+			t.Errorf("ParseRecurrence(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestNextRecurrenceRun(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// Friday 19:00 in Jakarta
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		cron     string
+		timezone string
+		want     time.Time
+	}{
+		{"0 18 * * *", "Asia/Jakarta", time.Date(2026, 10, 17, 18, 0, 0, 0, jakarta)},
+		{"0 20 * * *", "Asia/Jakarta", time.Date(2026, 10, 16, 20, 0, 0, 0, jakarta)},
+		{"0 18 * * 1-5", "Asia/Jakarta", time.Date(2026, 10, 19, 18, 0, 0, 0, jakarta)},
+		{"0 18 * * *", "UTC", time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		got, err := NextRecurrenceRun(models.ReleaseRecurrence{CronExpr: test.cron, Timezone: test.timezone}, now)
+		if err != nil {
+			t.Errorf("NextRecurrenceRun(%q, %s) error: %s", test.cron, test.timezone, err.Error())
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("NextRecurrenceRun(%q, %s) = %s, want %s", test.cron, test.timezone, got, test.want)
+		}
+	}
+
+	if _, err := NextRecurrenceRun(models.ReleaseRecurrence{CronExpr: "not a cron", Timezone: "UTC"}, now); err == nil {
+		t.Error("NextRecurrenceRun with an invalid cron expression, want an error")
+	}
+}
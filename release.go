@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// releaseExpireAfter is how late a pending release can still be fired, e.g. after the bot was down,
+// anything older is expired instead of being deployed at an unexpected time
+const releaseExpireAfter = 30 * time.Minute
+
+// releaseBuildTimeout is how long the bot follow a deployment before calling it failed
+const releaseBuildTimeout = 2 * time.Hour
+
+// runDueRelease fire or expire every pending release that is due at now
+func runDueRelease(client *slack.Client, now time.Time) {
+	for _, releaseSchedule := range models.GetDueRelease(now) {
+		releaseOn := time.Unix(int64(releaseSchedule.ReleaseOn), 0)
+		if now.Sub(releaseOn) > releaseExpireAfter {
+			log.Println("Expired Release", releaseSchedule.Id, releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion)
+			note := fmt.Sprintf("not fired within %s of the release time", releaseExpireAfter)
+			if models.TransitionRelease(releaseSchedule.Id, models.ReleasePending, models.ReleaseExpired, models.BotActor, note) {
+				notifyRelease(client, releaseSchedule, slack.Attachment{
+					Text:  fmt.Sprintf("Release of %s version %s is expired, it was %s", releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion, note),
+					Color: "#e20228",
+				})
+			}
+			continue
+		}
+
+		log.Println("OK Release", releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion)
+		notifyRelease(client, releaseSchedule, slack.Attachment{
+			Text:   fmt.Sprintf("Scheduled release of %s version %s is starting now.", releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion),
+			Color:  "#563a9b",
+			Footer: "GRIP Release Bot calling the deployer...",
+		})
+		if err := fireRelease(client, releaseSchedule, models.BotActor); err != nil {
+			log.Println("release not fired", releaseSchedule.Id, err.Error())
+		}
+	}
+}
+
+// fireRelease claim a pending release and start its deploy in the background, the pending > firing
+// transition make sure a release is only triggered once even when the ticker and a user race for it.
+// The deployer answer and the result are reported to the release thread so the ticker and the
+// interaction handler never wait on a slow CI backend
+func fireRelease(client *slack.Client, releaseSchedule models.ReleaseSchedule, actor string) error {
+	if !models.TransitionRelease(releaseSchedule.Id, models.ReleasePending, models.ReleaseFiring, actor, "") {
+		return fmt.Errorf("release %s is not pending anymore", releaseSchedule.Id)
+	}
+
+	go deployRelease(client, releaseSchedule)
+	return nil
+}
+
+// deployRelease call the project deployer for a firing release and follow the deployment
+func deployRelease(client *slack.Client, releaseSchedule models.ReleaseSchedule) {
+	deployer, deployment, err := startDeployment(releaseSchedule)
+	if err != nil {
+		log.Println("release failed", releaseSchedule.Id, err.Error())
+		models.TransitionRelease(releaseSchedule.Id, models.ReleaseFiring, models.ReleaseFailed, models.BotActor, err.Error())
+		notifyRelease(client, releaseSchedule, slack.Attachment{
+			Text:  fmt.Sprintf("The deployer did not accept the release of %s version %s: %s", releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion, err.Error()),
+			Color: "#e20228",
+		})
+		return
+	}
+
+	// the note mark the release as followed, failInterruptedReleases only fail those after a restart
+	note := deployment.Description()
+	if note == "" {
+		note = deployer.Name()
+	}
+	models.TransitionRelease(releaseSchedule.Id, models.ReleaseFiring, models.ReleaseTriggered, models.BotActor, note)
+	trackRelease(client, releaseSchedule, deployer, deployment)
+}
+
+func startDeployment(releaseSchedule models.ReleaseSchedule) (Deployer, Deployment, error) {
+	deployer, err := DeployerForProject(releaseSchedule.ReleaseProject)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	deployment, err := deployer.Deploy(ctx, newDeployRequest(releaseSchedule))
+	if err != nil {
+		log.Println(deployer.Name(), "deploy failed", err.Error())
+	}
+	return deployer, deployment, err
+}
+
+// trackRelease follow the deployment and post the progress and result to the release thread
+func trackRelease(client *slack.Client, releaseSchedule models.ReleaseSchedule, deployer Deployer, deployment Deployment) {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseBuildTimeout)
+	defer cancel()
+
+	status, err := deployment.Wait(ctx, func(status DeployStatus) {
+		notifyRelease(client, releaseSchedule, slack.Attachment{
+			Text:   fmt.Sprintf("%s started <%s|%s> of %s version %s.", deployer.Name(), status.URL, status.Name, releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion),
+			Color:  "#563a9b",
+			Footer: deployment.Description(),
+		})
+	})
+	if err != nil {
+		log.Println("cannot follow release", releaseSchedule.Id, err.Error())
+		models.TransitionRelease(releaseSchedule.Id, models.ReleaseTriggered, models.ReleaseFailed, models.BotActor, err.Error())
+		notifyRelease(client, releaseSchedule, slack.Attachment{
+			Text:  fmt.Sprintf("Release of %s version %s failed: %s", releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion, err.Error()),
+			Color: "#e20228",
+		})
+		return
+	}
+
+	if status.Accepted {
+		// nothing can follow the deployment, the release end once the deployer accepted it
+		models.TransitionRelease(releaseSchedule.Id, models.ReleaseTriggered, models.ReleaseSucceeded, models.BotActor, fmt.Sprintf("accepted by %s, the result is not followed", deployer.Name()))
+		target := deployer.Name()
+		if status.URL != "" {
+			target = fmt.Sprintf("<%s|%s>", status.URL, status.Name)
+		}
+		notifyRelease(client, releaseSchedule, slack.Attachment{
+			Text:   fmt.Sprintf("%s accepted the release of %s version %s, check %s for the result.", deployer.Name(), releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion, target),
+			Color:  "#4af030",
+			Footer: deployment.Description(),
+		})
+		return
+	}
+
+	duration := status.Duration.Round(time.Second)
+	note := fmt.Sprintf("%s %s in %s %s", status.Name, status.Result, duration, status.URL)
+	if status.Succeeded {
+		models.TransitionRelease(releaseSchedule.Id, models.ReleaseTriggered, models.ReleaseSucceeded, models.BotActor, note)
+		notifyRelease(client, releaseSchedule, slack.Attachment{
+			Text:   fmt.Sprintf("Yeay, %s version %s is released :rocket: <%s|%s> took %s", releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion, status.URL, status.Name, duration),
+			Color:  "#4af030",
+			Footer: deployment.Description(),
+		})
+		return
+	}
+
+	models.TransitionRelease(releaseSchedule.Id, models.ReleaseTriggered, models.ReleaseFailed, models.BotActor, note)
+	notifyRelease(client, releaseSchedule, slack.Attachment{
+		Text:   fmt.Sprintf("Oops, release of %s version %s ended with %s, <%s|%s> took %s", releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion, status.Result, status.URL, status.Name, duration),
+		Color:  "#e20228",
+		Footer: deployment.Description(),
+	})
+}
+
+// failInterruptedReleases mark the releases a previous run of the bot was still deploying as failed, nothing follow
+// their deployment anymore so the channel is told to check the result on the deployer. A release triggered before
+// releaseBuildTimeout would have timed out anyway and is left alone, like the triggered one migrated from the released flag
+func failInterruptedReleases(client *slack.Client) {
+	for _, releaseSchedule := range models.GetInterruptedReleases(time.Now().Add(-releaseBuildTimeout)) {
+		note := fmt.Sprintf("the bot restarted while the release was %s", releaseSchedule.State)
+		if !models.TransitionRelease(releaseSchedule.Id, releaseSchedule.State, models.ReleaseFailed, models.BotActor, note) {
+			continue
+		}
+
+		log.Println("Interrupted Release", releaseSchedule.Id, releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion)
+		notifyRelease(client, releaseSchedule, slack.Attachment{
+			Text:   fmt.Sprintf("Release of %s version %s is marked failed, %s.", releaseSchedule.ReleaseProject, releaseSchedule.ReleaseVersion, note),
+			Color:  "#e20228",
+			Footer: "Check the deployer for the real result before releasing again",
+		})
+	}
+}
+
+// notifyRelease post to the channel and thread the release was requested from
+func notifyRelease(client *slack.Client, releaseSchedule models.ReleaseSchedule, attachment slack.Attachment) {
+	if client == nil || releaseSchedule.Channel == "" {
+		return
+	}
+
+	options := []slack.MsgOption{slack.MsgOptionAttachments(attachment)}
+	if releaseSchedule.ThreadTs != "" {
+		options = append(options, slack.MsgOptionTS(releaseSchedule.ThreadTs))
+	}
+	if _, _, err := client.PostMessage(releaseSchedule.Channel, options...); err != nil {
+		log.Println("failed to post release progress", releaseSchedule.Id, err.Error())
+	}
+}
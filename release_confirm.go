@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/config"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// releaseConfirmTimeout is how long the Confirm button of an immediate release stay usable
+const releaseConfirmTimeout = 5 * time.Minute
+
+// block action id of the confirmation buttons
+const (
+	actionReleaseConfirm = "release_confirm"
+	actionReleaseCancel  = "release_cancel"
+)
+
+// releaseConfirmation is an immediate release waiting for the requester or an admin to confirm
+type releaseConfirmation struct {
+	Id            string
+	Project       string
+	Version       string
+	RequesterId   string
+	RequesterName string
+	Channel       string
+	ThreadTs      string
+	// MessageTs is the prompt message, it is updated once the prompt is answered or expired
+	MessageTs string
+	ExpiresAt time.Time
+}
+
+// confirmationStore keep the open prompts in memory, a restart simply expire them
+type confirmationStore struct {
+	mu    sync.Mutex
+	items map[string]*releaseConfirmation
+}
+
+var releaseConfirmations = &confirmationStore{items: map[string]*releaseConfirmation{}}
+
+func (s *confirmationStore) add(confirmation *releaseConfirmation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[confirmation.Id] = confirmation
+}
+
+func (s *confirmationStore) get(id string) *releaseConfirmation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.items[id]
+}
+
+// take remove the prompt, only the first caller get it so a double click release once
+func (s *confirmationStore) take(id string) *releaseConfirmation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	confirmation := s.items[id]
+	delete(s.items, id)
+	return confirmation
+}
+
+func (s *confirmationStore) takeExpired(now time.Time) []*releaseConfirmation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var expired []*releaseConfirmation
+	for id, confirmation := range s.items {
+		if now.After(confirmation.ExpiresAt) {
+			expired = append(expired, confirmation)
+			delete(s.items, id)
+		}
+	}
+	return expired
+}
+
+// askReleaseConfirmation post the release summary with Confirm and Cancel buttons
+func askReleaseConfirmation(req *CommandRequest, project string, version string) slack.Attachment {
+	confirmation := &releaseConfirmation{
+		Id:            uuid.New().String(),
+		Project:       project,
+		Version:       version,
+		RequesterId:   req.User.ID,
+		RequesterName: req.User.Name,
+		Channel:       req.Channel,
+		ThreadTs:      req.ThreadTs,
+		ExpiresAt:     time.Now().Add(releaseConfirmTimeout),
+	}
+
+	ts, err := req.Post(
+		slack.MsgOptionText(fmt.Sprintf("Release %s version %s?", project, version), false),
+		slack.MsgOptionBlocks(releaseConfirmationBlocks(confirmation, "", true)...),
+	)
+	if err != nil {
+		log.Println("failed to post release confirmation", err.Error())
+		return slack.Attachment{
+			Text:  fmt.Sprintf("Sorry <@%s>, I could not ask for the release confirmation, please try again", req.User.ID),
+			Color: "#e20228",
+		}
+	}
+
+	confirmation.MessageTs = ts
+	if confirmation.ThreadTs == "" {
+		// a slash command has no thread, the release progress follow the prompt
+		confirmation.ThreadTs = ts
+	}
+	releaseConfirmations.add(confirmation)
+	return slack.Attachment{}
+}
+
+// releaseConfirmationBlocks render the summary, outcome replace the buttons once the prompt is answered
+func releaseConfirmationBlocks(confirmation *releaseConfirmation, outcome string, withActions bool) []slack.Block {
+	target := "production"
+	if config.GetConfig("ENVIRONMENT") != "production" {
+		target = "production (test release)"
+	}
+
+	deployed := "unknown"
+	if last, ok := models.GetLastRelease(confirmation.Project); ok {
+		deployed = fmt.Sprintf("%s (%s)", last.ReleaseVersion, last.State)
+	}
+
+	blocks := []slack.Block{
+		slack.NewSectionBlock(
+			slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("<@%s> wants to release *%s* now, please confirm :eyes:", confirmation.RequesterId, confirmation.Project), false, false),
+			[]*slack.TextBlockObject{
+				slack.NewTextBlockObject(slack.MarkdownType, "*Project*\n"+confirmation.Project, false, false),
+				slack.NewTextBlockObject(slack.MarkdownType, "*Version*\n"+confirmation.Version, false, false),
+				slack.NewTextBlockObject(slack.MarkdownType, "*Target*\n"+target, false, false),
+				slack.NewTextBlockObject(slack.MarkdownType, "*Currently deployed*\n"+deployed, false, false),
+			},
+			nil,
+		),
+	}
+
+	if withActions {
+		blocks = append(blocks,
+			slack.NewActionBlock("release_confirmation",
+				slack.NewButtonBlockElement(actionReleaseConfirm, confirmation.Id, slack.NewTextBlockObject(slack.PlainTextType, "Confirm", false, false)).WithStyle(slack.StylePrimary),
+				slack.NewButtonBlockElement(actionReleaseCancel, confirmation.Id, slack.NewTextBlockObject(slack.PlainTextType, "Cancel", false, false)).WithStyle(slack.StyleDanger),
+			),
+			slack.NewContextBlock("",
+				slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("Only <@%s> or an admin can confirm, expires in %s.", confirmation.RequesterId, releaseConfirmTimeout), false, false),
+			),
+		)
+	}
+
+	if outcome != "" {
+		blocks = append(blocks, slack.NewContextBlock("",
+			slack.NewTextBlockObject(slack.MarkdownType, outcome, false, false),
+		))
+	}
+
+	return blocks
+}
+
+// handleReleaseConfirmation answer a Confirm or Cancel click on a release prompt
+func handleReleaseConfirmation(callback slack.InteractionCallback, action *slack.BlockAction, client *slack.Client) error {
+	confirmation := releaseConfirmations.get(action.Value)
+	if confirmation == nil {
+		_, err := client.PostEphemeral(callback.Channel.ID, callback.User.ID, slack.MsgOptionText("Sorry, this release prompt is expired or already answered.", false))
+		return err
+	}
+
+	if callback.User.ID != confirmation.RequesterId && !PermissionAdmin.AllowsProject(callback.User.ID, confirmation.Project) {
+		_, err := client.PostEphemeral(callback.Channel.ID, callback.User.ID, slack.MsgOptionText(fmt.Sprintf("Sorry, only <@%s> or an admin can answer this release prompt 🙏", confirmation.RequesterId), false))
+		return err
+	}
+
+	if confirmation = releaseConfirmations.take(action.Value); confirmation == nil {
+		return nil
+	}
+
+	if action.ActionID == actionReleaseCancel {
+		return updateReleaseConfirmation(client, confirmation, fmt.Sprintf(":no_entry_sign: Cancelled by <@%s>", callback.User.ID))
+	}
+
+	if !models.ProjectIsAvailable(confirmation.Project) {
+		return updateReleaseConfirmation(client, confirmation, fmt.Sprintf(":warning: Project %s is not available anymore", confirmation.Project))
+	}
+
+	// record the release as a schedule for now so it show up in the release history
+	id, err := models.CreateSchedule(confirmation.Project, confirmation.Version, time.Now(), confirmation.RequesterName, confirmation.Channel, confirmation.ThreadTs)
+	if err != nil {
+		return updateReleaseConfirmation(client, confirmation, ":warning: I could not record the release, please try again")
+	}
+	releaseSchedule, ok := models.GetSchedule(id)
+	if !ok {
+		return updateReleaseConfirmation(client, confirmation, ":warning: I could not record the release, please try again")
+	}
+
+	outcome := fmt.Sprintf(":rocket: Confirmed by <@%s>, releasing now, I will report the build in the thread.", callback.User.ID)
+	if err := fireRelease(client, releaseSchedule, callback.User.Name); err != nil {
+		outcome = fmt.Sprintf(":x: Confirmed by <@%s> but the release did not start: %s", callback.User.ID, err.Error())
+	}
+	return updateReleaseConfirmation(client, confirmation, outcome)
+}
+
+// expireReleaseConfirmations close the prompts nobody answered in time
+func expireReleaseConfirmations(client *slack.Client, now time.Time) {
+	for _, confirmation := range releaseConfirmations.takeExpired(now) {
+		auditBot("expire release confirmation", fmt.Sprintf("%s <<%s>> of %s", confirmation.Project, confirmation.Version, confirmation.RequesterId))
+		if err := updateReleaseConfirmation(client, confirmation, ":hourglass: Expired, nobody confirmed the release in time"); err != nil {
+			log.Println(err.Error())
+		}
+	}
+}
+
+func updateReleaseConfirmation(client *slack.Client, confirmation *releaseConfirmation, outcome string) error {
+	_, _, _, err := client.UpdateMessage(confirmation.Channel, confirmation.MessageTs,
+		slack.MsgOptionText(fmt.Sprintf("Release %s version %s", confirmation.Project, confirmation.Version), false),
+		slack.MsgOptionBlocks(releaseConfirmationBlocks(confirmation, outcome, false)...),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update release confirmation: %w", err)
+	}
+	return nil
+}
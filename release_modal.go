@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// callback id of the global shortcut and of the modal it open
+const (
+	releaseScheduleShortcut = "release_schedule"
+	releaseScheduleModal    = "release_schedule_modal"
+)
+
+// block id of the release schedule modal inputs, the action id is the same as the block id
+const (
+	scheduleBlockProject = "schedule_project"
+	scheduleBlockVersion = "schedule_version"
+	scheduleBlockDate    = "schedule_date"
+	scheduleBlockTime    = "schedule_time"
+	scheduleBlockChannel = "schedule_channel"
+)
+
+// handleReleaseScheduleCommand open the schedule modal for /release-schedule, the text can preselect the project
+func handleReleaseScheduleCommand(command slack.SlashCommand, client *slack.Client) error {
+	return openReleaseScheduleModal(client, command.TriggerID, command.UserID, command.ChannelID, strings.TrimSpace(command.Text))
+}
+
+// openReleaseScheduleModal show the modal to users with release access, channel is where the release progress
+// is posted by default and stay empty for the global shortcut
+func openReleaseScheduleModal(client *slack.Client, triggerId string, userId string, channel string, project string) error {
+	if !PermissionRelease.Allows(userId) {
+		return postEphemeralOrDM(client, channel, userId, fmt.Sprintf("Sorry <@%s>, you are not allowed to schedule a release", userId))
+	}
+
+	// only the projects the user can release are offered
+	var projects []string
+	for _, name := range models.GetEnabledProjectNames() {
+		if PermissionRelease.AllowsProject(userId, name) {
+			projects = append(projects, name)
+		}
+	}
+	if len(projects) == 0 {
+		return postEphemeralOrDM(client, channel, userId, fmt.Sprintf("Sorry <@%s>, there is no enabled project you can release", userId))
+	}
+
+	user, err := client.GetUserInfo(userId)
+	if err != nil {
+		return fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	_, err = client.OpenView(triggerId, releaseScheduleView(user, projects, channel, strings.ToLower(project)))
+	if err != nil {
+		return fmt.Errorf("failed to open release schedule modal: %w", err)
+	}
+	return nil
+}
+
+func releaseScheduleView(user *slack.User, projects []string, channel string, project string) slack.ModalViewRequest {
+	var options []*slack.OptionBlockObject
+	var initial *slack.OptionBlockObject
+	for _, name := range projects {
+		option := slack.NewOptionBlockObject(name, slack.NewTextBlockObject(slack.PlainTextType, name, false, false), nil)
+		options = append(options, option)
+		if name == project {
+			initial = option
+		}
+	}
+	projectSelect := slack.NewOptionsSelectBlockElement(slack.OptTypeStatic, slack.NewTextBlockObject(slack.PlainTextType, "Select a project", false, false), scheduleBlockProject, options...)
+	projectSelect.InitialOption = initial
+
+	timezone := "the project timezone"
+	now := time.Now().In(DefaultLocation())
+	if location := userOwnLocation(user); location != nil {
+		timezone = location.String()
+		now = time.Now().In(location)
+	}
+
+	datePicker := slack.NewDatePickerBlockElement(scheduleBlockDate)
+	datePicker.InitialDate = now.Format("2006-01-02")
+	timePicker := slack.NewTimePickerBlockElement(scheduleBlockTime)
+
+	channelSelect := slack.NewOptionsSelectBlockElement(slack.OptTypeConversations, slack.NewTextBlockObject(slack.PlainTextType, "Select a channel", false, false), scheduleBlockChannel)
+	channelSelect.InitialConversation = channel
+	channelInput := slack.NewInputBlock(scheduleBlockChannel,
+		slack.NewTextBlockObject(slack.PlainTextType, "Post progress in", false, false),
+		slack.NewTextBlockObject(slack.PlainTextType, "Leave empty to get the progress in a direct message", false, false),
+		channelSelect)
+	channelInput.Optional = true
+
+	return slack.ModalViewRequest{
+		Type:       slack.VTModal,
+		CallbackID: releaseScheduleModal,
+		Title:      slack.NewTextBlockObject(slack.PlainTextType, "Schedule a release", false, false),
+		Submit:     slack.NewTextBlockObject(slack.PlainTextType, "Schedule", false, false),
+		Close:      slack.NewTextBlockObject(slack.PlainTextType, "Cancel", false, false),
+		Blocks: slack.Blocks{BlockSet: []slack.Block{
+			slack.NewInputBlock(scheduleBlockProject, slack.NewTextBlockObject(slack.PlainTextType, "Project", false, false), nil, projectSelect),
+			slack.NewInputBlock(scheduleBlockVersion, slack.NewTextBlockObject(slack.PlainTextType, "Version", false, false), nil,
+				slack.NewPlainTextInputBlockElement(slack.NewTextBlockObject(slack.PlainTextType, "backend-1.1.0-beta", false, false), scheduleBlockVersion)),
+			slack.NewInputBlock(scheduleBlockDate, slack.NewTextBlockObject(slack.PlainTextType, "Date", false, false), nil, datePicker),
+			slack.NewInputBlock(scheduleBlockTime, slack.NewTextBlockObject(slack.PlainTextType, "Time", false, false), nil, timePicker),
+			channelInput,
+			slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("Date and time are in %s, use `set timezone` to change it.", timezone), false, false)),
+		}},
+	}
+}
+
+// handleReleaseScheduleSubmission validate the modal like the schedule release command, the returned response
+// show the errors next to the inputs and is nil when the schedule is created and the modal can close
+func handleReleaseScheduleSubmission(callback slack.InteractionCallback, client *slack.Client) *slack.ViewSubmissionResponse {
+	values := callback.View.State.Values
+	project := values[scheduleBlockProject][scheduleBlockProject].SelectedOption.Value
+	version := strings.TrimSpace(values[scheduleBlockVersion][scheduleBlockVersion].Value)
+	date := values[scheduleBlockDate][scheduleBlockDate].SelectedDate
+	clock := values[scheduleBlockTime][scheduleBlockTime].SelectedTime
+	channel := values[scheduleBlockChannel][scheduleBlockChannel].SelectedConversation
+
+	if !PermissionRelease.AllowsProject(callback.User.ID, project) {
+		return slack.NewErrorsViewSubmissionResponse(map[string]string{scheduleBlockProject: fmt.Sprintf("You are not allowed to release %s in %s", project, currentEnvironment())})
+	}
+	if version == "" || strings.ContainsAny(version, "<>") {
+		return slack.NewErrorsViewSubmissionResponse(map[string]string{scheduleBlockVersion: "Version cannot be empty or contain < >"})
+	}
+
+	user, err := client.GetUserInfo(callback.User.ID)
+	if err != nil {
+		log.Println("failed to get user info", err.Error())
+		return slack.NewErrorsViewSubmissionResponse(map[string]string{scheduleBlockProject: "I could not read your profile, please try again"})
+	}
+
+	releaseOn, location, err := validateSchedule(user, project, date+" "+clock)
+	if errors.Is(err, errProjectNotAvailable) {
+		return slack.NewErrorsViewSubmissionResponse(map[string]string{scheduleBlockProject: fmt.Sprintf("Project %s is not available anymore", project)})
+	}
+	if errors.Is(err, errReleaseNotAllowed) {
+		return slack.NewErrorsViewSubmissionResponse(map[string]string{scheduleBlockProject: fmt.Sprintf("You are not allowed to release %s in %s", project, currentEnvironment())})
+	}
+	if err != nil {
+		return slack.NewErrorsViewSubmissionResponse(map[string]string{scheduleBlockTime: fmt.Sprintf("Looks like your time is wrong (%s), it is read in %s timezone", err.Error(), location.String())})
+	}
+
+	if channel == "" {
+		// posting to the user id land in the direct message with the bot
+		channel = user.ID
+	}
+
+	id, err := models.CreateSchedule(project, version, releaseOn, user.Name, channel, "")
+	if err != nil {
+		return slack.NewErrorsViewSubmissionResponse(map[string]string{scheduleBlockProject: "I could not record the schedule, please try again"})
+	}
+
+	_, _, err = client.PostMessage(channel, slack.MsgOptionAttachments(slack.Attachment{
+		Text:   fmt.Sprintf("Roger <@%s>, Create release schedule for %s version %s at %s", user.ID, project, version, models.FormatTime(int(releaseOn.Unix()), location)),
+		Color:  "#4af030",
+		Footer: fmt.Sprintf("GRIP Release Bot create release schedule. Schedule Id: %s", id),
+	}))
+	if err != nil {
+		log.Println("failed to post release schedule", id, err.Error())
+	}
+	return nil
+}
+
+// postEphemeralOrDM reply only to the user, a global shortcut has no channel so it fall back to a direct message
+func postEphemeralOrDM(client *slack.Client, channel string, userId string, text string) error {
+	attachment := slack.MsgOptionAttachments(slack.Attachment{Text: text, Color: "#e20228", Footer: "GRIP Release Bot cannot continue"})
+	if channel == "" {
+		_, _, err := client.PostMessage(userId, attachment)
+		return err
+	}
+	_, err := client.PostEphemeral(channel, userId, attachment)
+	return err
+}
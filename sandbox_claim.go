@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// sandboxClaimReminder is how long before the claim expiry the holder is asked to extend or release
+const sandboxClaimReminder = 30 * time.Minute
+
+// block action id of the claim reminder buttons, the value is project|server
+const (
+	actionSandboxClaimExtend  = "sandbox_claim_extend"
+	actionSandboxClaimRelease = "sandbox_claim_release"
+)
+
+// runSandboxClaims remind the holders of the claims about to expire and free the expired one,
+// reservations of a waitlist offer are left to expireSandboxOffers
+func runSandboxClaims(client *slack.Client, now time.Time) {
+	for _, server := range models.GetClaimsToRemind(now.Add(sandboxClaimReminder)) {
+		if _, offered := models.GetWaitlistOffer(server.Project, server.ServerId); offered {
+			continue
+		}
+		models.SetClaimReminded(server.Project, server.ServerId)
+		if err := remindSandboxClaim(client, server); err != nil {
+			log.Println(err.Error())
+		}
+	}
+
+	for _, server := range models.GetExpiredClaims(now) {
+		if _, offered := models.GetWaitlistOffer(server.Project, server.ServerId); offered {
+			continue
+		}
+		if !models.ExpireClaim(server.Project, server.ServerId, server.ClaimUntil, models.BotActor) {
+			continue
+		}
+		log.Println("Expired sandbox claim", server.Project, server.ServerId, server.ClaimedById)
+		auditBot("expire sandbox claim", fmt.Sprintf("%s,%s of %s", server.Project, server.ServerId, server.ClaimedById))
+		_, _, err := client.PostMessage(server.ClaimedById, slack.MsgOptionAttachments(slack.Attachment{
+			Text:   fmt.Sprintf("Hi <@%s>, your claim of sandbox server %s of %s expired, I released it for the next person.", server.ClaimedById, server.ServerId, server.Project),
+			Color:  "#563a9b",
+			Footer: fmt.Sprintf("Use claim sandbox server %s,%s to claim it again.", server.Project, server.ServerId),
+		}))
+		if err != nil {
+			log.Println("failed to notify sandbox claim expiry", server.ClaimedById, err.Error())
+		}
+		offerSandboxServer(client, server.Project, server.ServerId)
+	}
+}
+
+func remindSandboxClaim(client *slack.Client, server models.TestingStatus) error {
+	location := DefaultLocation()
+	if user, err := client.GetUserInfo(server.ClaimedById); err == nil {
+		location = UserLocation(user)
+	}
+
+	value := server.Project + "|" + server.ServerId
+	_, _, err := client.PostMessage(server.ClaimedById,
+		slack.MsgOptionText(fmt.Sprintf("Your claim of sandbox server %s of %s is about to expire", server.ServerId, server.Project), false),
+		slack.MsgOptionBlocks(
+			slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("Hi <@%s>, your claim of sandbox server *%s* of *%s* expire at %s. Still using it?", server.ClaimedById, server.ServerId, server.Project, models.FormatTime(server.ClaimUntil, location)), false, false), nil, nil),
+			slack.NewActionBlock("sandbox_claim",
+				slack.NewButtonBlockElement(actionSandboxClaimExtend, value, slack.NewTextBlockObject(slack.PlainTextType, fmt.Sprintf("Extend %s", sandboxClaimDefault), false, false)).WithStyle(slack.StylePrimary),
+				slack.NewButtonBlockElement(actionSandboxClaimRelease, value, slack.NewTextBlockObject(slack.PlainTextType, "Release", false, false)),
+			),
+			slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, "Without an answer the server is released automatically.", false, false)),
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remind sandbox claim: %w", err)
+	}
+	return nil
+}
+
+// handleSandboxClaimReminder answer the Extend or Release click of a claim reminder
+func handleSandboxClaimReminder(callback slack.InteractionCallback, action *slack.BlockAction, client *slack.Client) error {
+	project, serverId, _ := strings.Cut(action.Value, "|")
+
+	outcome := ""
+	server, ok := models.GetServer(project, serverId)
+	switch {
+	case !ok || !server.Status || server.ClaimedById != callback.User.ID:
+		outcome = "You do not hold this server anymore."
+	case action.ActionID == actionSandboxClaimExtend:
+		until := time.Now().Add(sandboxClaimDefault)
+		if !models.ExtendClaim(project, serverId, callback.User.ID, until) {
+			outcome = "You do not hold this server anymore."
+			break
+		}
+		location := DefaultLocation()
+		if user, err := client.GetUserInfo(callback.User.ID); err == nil {
+			location = UserLocation(user)
+		}
+		outcome = fmt.Sprintf(":white_check_mark: Extended, the server is yours until %s.", models.FormatTime(int(until.Unix()), location))
+	default:
+		name := callback.User.Name
+		if user, err := client.GetUserInfo(callback.User.ID); err == nil {
+			name = sandboxUserName(user)
+		}
+		releaseSandboxServer(client, project, serverId, name)
+		outcome = ":wave: Released, thanks for giving it back."
+	}
+
+	_, _, _, err := client.UpdateMessage(callback.Channel.ID, callback.Message.Timestamp,
+		slack.MsgOptionText(fmt.Sprintf("Sandbox server %s of %s", serverId, project), false),
+		slack.MsgOptionBlocks(
+			slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("Your claim of sandbox server *%s* of *%s*", serverId, project), false, false), nil, nil),
+			slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, outcome, false, false)),
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update sandbox claim reminder: %w", err)
+	}
+	return nil
+}
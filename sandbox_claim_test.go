@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+func TestRunSandboxClaims(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		until    time.Duration
+		reminded bool
+		offered  bool
+		// holder is who hold sb1 after the run, empty when it is free
+		holder   string
+		dms      string
+		reminder bool
+	}{
+		{"far from the expiry", 2 * time.Hour, false, false, "U0ALICE", "", false},
+		{"about to expire", 10 * time.Minute, false, false, "U0ALICE", "U0ALICE", true},
+		{"already reminded", 10 * time.Minute, true, false, "U0ALICE", "", true},
+		{"expired goes to the waitlist", -time.Minute, true, false, "U0BOB", "U0ALICE,U0BOB", false},
+		{"reserved for an offer", -time.Minute, false, true, "U0ALICE", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sandbox := newFakeSandbox("sb1", "U0BOB")
+			server := sandbox.servers["sb1"]
+			server.Status, server.ClaimedById, server.ClaimReason, server.ClaimUntil = true, "U0ALICE", "qa", int(now.Add(test.until).Unix())
+			sandbox.reminded["sb1"] = test.reminded
+			sandbox.history = []*models.SandboxClaimHistory{{Id: "h1", Project: "grip", ServerId: "sb1", SlackId: "U0ALICE", StartedAt: int(now.Add(-time.Hour).Unix())}}
+			if test.offered {
+				server.ClaimReason = sandboxOfferReason
+				sandbox.history = nil
+				sandbox.waitlist = []*models.SandboxWaitlist{{Id: "w-U0ALICE", Project: "grip", SlackId: "U0ALICE", Status: models.WaitlistOffered, ServerId: "sb1", OfferExpiresAt: int(now.Add(time.Minute).Unix())}}
+			}
+			useFakeDB(t, sandbox.handle)
+			client, slackAPI := newFakeSlackClient(t)
+
+			runSandboxClaims(client, now)
+
+			if server.ClaimedById != test.holder || server.Status != (test.holder != "") {
+				t.Errorf("sb1 is held by %q (status %v), want %q", server.ClaimedById, server.Status, test.holder)
+			}
+			if dms := strings.Join(slackAPI.dms(), ","); dms != test.dms {
+				t.Errorf("messages sent to %q, want %q", dms, test.dms)
+			}
+			if sandbox.reminded["sb1"] != test.reminder {
+				t.Errorf("reminded = %v, want %v", sandbox.reminded["sb1"], test.reminder)
+			}
+			if expired := test.holder != "U0ALICE"; len(sandbox.history) > 0 && (sandbox.history[0].EndedAt != 0) != expired {
+				t.Errorf("claim history of alice = %+v, want it closed only when the claim expired", sandbox.history[0])
+			}
+		})
+	}
+}
+
+func TestHandleSandboxClaimReminder(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		actionId string
+		holder   string
+		extended bool
+	}{
+		{"extend", "U0ALICE", actionSandboxClaimExtend, "U0ALICE", true},
+		{"release", "U0ALICE", actionSandboxClaimRelease, "", false},
+		{"extend a server held by someone else", "U0BOB", actionSandboxClaimExtend, "U0ALICE", false},
+		{"release a server held by someone else", "U0BOB", actionSandboxClaimRelease, "U0ALICE", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sandbox := newFakeSandbox("sb1")
+			server := sandbox.servers["sb1"]
+			until := int(time.Now().Add(10 * time.Minute).Unix())
+			server.Status, server.ClaimedById, server.ClaimReason, server.ClaimUntil = true, "U0ALICE", "qa", until
+			sandbox.reminded["sb1"] = true
+			useFakeDB(t, sandbox.handle)
+			client, _ := newFakeSlackClient(t)
+
+			callback := slack.InteractionCallback{User: slack.User{ID: test.user}, Channel: slack.Channel{GroupConversation: slack.GroupConversation{Conversation: slack.Conversation{ID: "D1"}}}}
+			err := handleSandboxClaimReminder(callback, &slack.BlockAction{ActionID: test.actionId, Value: "grip|sb1"}, client)
+			if err != nil {
+				t.Fatalf("error: %s", err.Error())
+			}
+
+			if server.ClaimedById != test.holder {
+				t.Errorf("sb1 is held by %q, want %q", server.ClaimedById, test.holder)
+			}
+			if extended := server.ClaimUntil > until; extended != test.extended {
+				t.Errorf("claim until moved from %d to %d, want extended %v", until, server.ClaimUntil, test.extended)
+			}
+			if test.extended && sandbox.reminded["sb1"] {
+				t.Errorf("an extended claim is still marked as reminded")
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/config"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// sandboxBuildTimeout is how long the bot follow a sandbox build before giving up
+const sandboxBuildTimeout = time.Hour
+
+var sandboxBranchArg = regexp.MustCompile(`(?i)\b(fe|be)=(\S+)`)
+
+// handleSandboxDeploy claim the server for the caller and trigger the sandbox Jenkins job with the branches,
+// they are recorded on testing_status once the build succeed. A server claimed by someone else is only taken over by an admin
+func handleSandboxDeploy(req *CommandRequest) slack.Attachment {
+	project, serverId := strings.ToLower(req.Args[1]), req.Args[2]
+	name := sandboxUserName(req.User)
+	if !PermissionSandbox.AllowsProject(req.User.ID, project) {
+		return permissionDenied(req, project)
+	}
+
+	server, ok := models.GetServer(project, serverId)
+	if !ok {
+		return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, there is no sandbox server %s for project %s.", req.User.ID, serverId, project), Color: "#e20228"}
+	}
+
+	feBranch, beBranch := server.LastFeBranch, server.LastBeBranch
+	for _, match := range sandboxBranchArg.FindAllStringSubmatch(req.Args[3], -1) {
+		if strings.EqualFold(match[1], "fe") {
+			feBranch = match[2]
+		} else {
+			beBranch = match[2]
+		}
+	}
+
+	host, token := config.GetConfig("SANDBOX_JENKINS_HOST"), config.GetConfig("SANDBOX_JENKINS_TOKEN")
+	if host == "" || token == "" {
+		return slack.Attachment{
+			Text:   fmt.Sprintf("Sorry <@%s>, the sandbox Jenkins job is not configured, ask an admin to set SANDBOX_JENKINS_HOST and SANDBOX_JENKINS_TOKEN", req.User.ID),
+			Color:  "#e20228",
+			Footer: "GRIP Release Bot cannot continue",
+		}
+	}
+
+	until := time.Now().Add(sandboxClaimDefault)
+	reason := fmt.Sprintf("deploy FE %s BE %s", orNone(feBranch), orNone(beBranch))
+	if !models.ClaimServer(project, serverId, req.User.ID, name, reason, until) {
+		if !PermissionAdmin.AllowsProject(req.User.ID, project) {
+			if current, ok := models.GetServer(project, serverId); ok {
+				server = current
+			}
+			return sandboxServerInUse(req, server, UserLocation(req.User))
+		}
+
+		// an admin take the server over, an open offer is closed and the previous holder is told
+		previous, _ := models.GetServer(project, serverId)
+		freeSandboxServer(req.Client, project, serverId, name)
+		if !models.ClaimServer(project, serverId, req.User.ID, name, reason, until) {
+			offerSandboxServer(req.Client, project, serverId)
+			return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, I could not claim sandbox server %s of %s, please try again", req.User.ID, serverId, project), Color: "#e20228"}
+		}
+		if previous.ClaimedById != "" && previous.ClaimedById != req.User.ID {
+			_, _, err := req.Client.PostMessage(previous.ClaimedById, slack.MsgOptionText(fmt.Sprintf("Hi <@%s>, <@%s> took over sandbox server %s of %s to deploy FE %s and BE %s.", previous.ClaimedById, req.User.ID, serverId, project, orNone(feBranch), orNone(beBranch)), false))
+			if err != nil {
+				log.Println("failed to notify sandbox take over", previous.ClaimedById, err.Error())
+			}
+		}
+	}
+
+	params := url.Values{}
+	params.Set("token", token)
+	params.Set("project", project)
+	params.Set("server_id", serverId)
+	params.Set("fe_branch", feBranch)
+	params.Set("be_branch", beBranch)
+	params.Set("build_by", req.User.ID)
+
+	go deploySandbox(req, NewJenkinsClient(host), params, project, serverId, feBranch, beBranch)
+
+	return slack.Attachment{
+		Text:   fmt.Sprintf("On it <@%s>, deploying FE *%s* and BE *%s* to sandbox server %s of %s, the server is yours until %s", req.User.ID, orNone(feBranch), orNone(beBranch), serverId, project, models.FormatTime(int(until.Unix()), UserLocation(req.User))),
+		Color:  "#4af030",
+		Footer: "I will report the build in this thread.",
+	}
+}
+
+// deploySandbox trigger the sandbox Jenkins job and follow the build, a refused trigger is reported like the build result
+func deploySandbox(req *CommandRequest, jenkins *JenkinsClient, params url.Values, project string, serverId string, feBranch string, beBranch string) {
+	trigger, err := jenkins.TriggerWebhook(params)
+	if err != nil {
+		log.Println("sandbox deploy failed", project, serverId, err.Error())
+		attachment := slack.Attachment{
+			Text:   fmt.Sprintf("Sorry <@%s>, Jenkins did not accept the deploy to sandbox server %s of %s: %s", req.User.ID, serverId, project, err.Error()),
+			Color:  "#e20228",
+			Footer: "The server stay claimed by you, use release sandbox server to give it back.",
+		}
+		if _, err := req.Post(slack.MsgOptionAttachments(attachment), slack.MsgOptionTS(req.ThreadTs)); err != nil {
+			log.Println("failed to post sandbox deploy failure", err.Error())
+		}
+		return
+	}
+
+	followSandboxBuild(req, jenkins, trigger, project, serverId, feBranch, beBranch)
+}
+
+// followSandboxBuild record the branches of a successful build on the server and report the result
+// with req.Post, where the deploy was asked and only to the caller for a private slash command
+func followSandboxBuild(req *CommandRequest, jenkins *JenkinsClient, trigger *JenkinsTrigger, project string, serverId string, feBranch string, beBranch string) {
+	ctx, cancel := context.WithTimeout(context.Background(), sandboxBuildTimeout)
+	defer cancel()
+
+	build, err := jenkins.WaitForBuild(ctx, trigger, nil)
+	attachment := slack.Attachment{
+		Text:  fmt.Sprintf("<@%s> sandbox server %s of %s is deployed :rocket: <%s|#%d> took %s", req.User.ID, serverId, project, build.URL, build.Number, build.Duration.Round(time.Second)),
+		Color: "#4af030",
+	}
+	switch {
+	case err != nil:
+		log.Println("cannot follow sandbox build", project, serverId, err.Error())
+		attachment.Text = fmt.Sprintf("<@%s> I could not follow the deploy to sandbox server %s of %s: %s", req.User.ID, serverId, project, err.Error())
+		attachment.Color = "#e20228"
+	case build.Result != "SUCCESS":
+		attachment.Text = fmt.Sprintf("<@%s> deploy to sandbox server %s of %s ended with %s, <%s|#%d>", req.User.ID, serverId, project, build.Result, build.URL, build.Number)
+		attachment.Color = "#e20228"
+	default:
+		models.UpdateServerBuild(project, serverId, sandboxUserName(req.User), feBranch, beBranch)
+	}
+
+	if _, err := req.Post(slack.MsgOptionAttachments(attachment), slack.MsgOptionTS(req.ThreadTs)); err != nil {
+		log.Println("failed to post sandbox build result", err.Error())
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+func TestSandboxDeploy(t *testing.T) {
+	tests := []struct {
+		name string
+		role string
+		// offered reserve sb1 for a waitlist offer of alice before the deploy
+		offered bool
+		holder  string
+		alice   string
+	}{
+		{"free server", "sandbox", false, "U0ADMIN", models.WaitlistWaiting},
+		{"offered server", "sandbox", true, "U0ALICE", models.WaitlistOffered},
+		{"admin take over an offered server", "admin", true, "U0ADMIN", models.WaitlistPassed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			jenkins := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+			}))
+			t.Cleanup(jenkins.Close)
+			t.Setenv("SANDBOX_JENKINS_HOST", jenkins.URL)
+			t.Setenv("SANDBOX_JENKINS_TOKEN", "sandbox-token")
+			t.Setenv("DEFAULT_ROLE", test.role)
+
+			sandbox := newFakeSandbox("sb1", "U0ALICE")
+			if test.offered {
+				alice := sandbox.entry("U0ALICE")
+				alice.Status, alice.ServerId, alice.OfferChannel, alice.OfferTs = models.WaitlistOffered, "sb1", "D1", "1.1"
+				sandbox.servers["sb1"].Status, sandbox.servers["sb1"].ClaimedById = true, "U0ALICE"
+			}
+			useFakeDB(t, sandbox.handle)
+			client, _ := newFakeSlackClient(t)
+
+			posted := make(chan string, 1)
+			attachment := handleSandboxDeploy(&CommandRequest{
+				Client: client,
+				User:   &slack.User{ID: "U0ADMIN", Name: "admin"},
+				Args:   []string{"grip sb1 fe=main", "grip", "sb1", " fe=main"},
+				Post: func(options ...slack.MsgOption) (string, error) {
+					_, values, _ := slack.UnsafeApplyMsgOptions("", "", "", options...)
+					posted <- values.Get("attachments")
+					return "1.1", nil
+				},
+			})
+
+			server := sandbox.servers["sb1"]
+			if server.ClaimedById != test.holder {
+				t.Errorf("sb1 is held by %q, want %q", server.ClaimedById, test.holder)
+			}
+			if alice := sandbox.entry("U0ALICE"); alice.Status != test.alice {
+				t.Errorf("alice = %+v, want %s", alice, test.alice)
+			}
+			if test.holder != "U0ADMIN" {
+				if !strings.Contains(attachment.Text, "in use") {
+					t.Errorf("reply = %q, want the server in use", attachment.Text)
+				}
+				return
+			}
+
+			// the reply does not wait for Jenkins, the refused trigger is reported in the thread
+			if !strings.Contains(attachment.Text, "On it") {
+				t.Errorf("reply = %q, want the deploy started", attachment.Text)
+			}
+			select {
+			case text := <-posted:
+				if !strings.Contains(text, "Jenkins did not accept the deploy") {
+					t.Errorf("follow up = %q, want the refused trigger", text)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("the refused trigger was not reported")
+			}
+		})
+	}
+}
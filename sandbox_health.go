@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// sandboxHealthInterval is how often the sandbox health urls are probed
+const sandboxHealthInterval = time.Minute
+
+// sandboxHealthTimeout is how long a probe wait before the server is reported down
+const sandboxHealthTimeout = 5 * time.Second
+
+// sandboxHealthBodyLimit cap what is read from a health endpoint, only the version is needed
+const sandboxHealthBodyLimit = 64 * 1024
+
+// plainVersion match a plain text body that is only a version, e.g. 1.4.2 or v1.4.2-rc1, and not "OK"
+var plainVersion = regexp.MustCompile(`^v?\d+(\.\d+)*([-+.][\w.-]+)?$`)
+
+var sandboxHealthHTTP = &http.Client{Timeout: sandboxHealthTimeout}
+
+// sandboxHealthProbe keep the ticker from starting a new round while the last one is still running
+var sandboxHealthProbe = struct {
+	sync.Mutex
+	running bool
+	last    time.Time
+}{}
+
+// probeSandboxServers probe the health url of every enabled sandbox server once per interval,
+// the probes run in the background so a slow server never delay the ticker
+func probeSandboxServers(now time.Time) {
+	sandboxHealthProbe.Lock()
+	defer sandboxHealthProbe.Unlock()
+	if sandboxHealthProbe.running || now.Sub(sandboxHealthProbe.last) < sandboxHealthInterval {
+		return
+	}
+	sandboxHealthProbe.running = true
+	sandboxHealthProbe.last = now
+
+	go func() {
+		defer func() {
+			sandboxHealthProbe.Lock()
+			sandboxHealthProbe.running = false
+			sandboxHealthProbe.Unlock()
+		}()
+
+		var wg sync.WaitGroup
+		for _, server := range models.GetServersToProbe() {
+			wg.Add(1)
+			go func(server models.TestingStatus) {
+				defer wg.Done()
+				models.SetServerHealth(server.Project, server.ServerId, server.HealthURL, checkSandboxHealth(server.HealthURL))
+			}(server)
+		}
+		wg.Wait()
+	}()
+}
+
+// checkSandboxHealth call the health url, any 2xx answer is up. The version is read from a JSON body
+// with a version field, a plain text body holding only the version or the X-Version header
+func checkSandboxHealth(url string) models.SandboxHealth {
+	ctx, cancel := context.WithTimeout(context.Background(), sandboxHealthTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return models.SandboxHealth{Error: "invalid health url"}
+	}
+
+	start := time.Now()
+	resp, err := sandboxHealthHTTP.Do(req)
+	if err != nil {
+		return models.SandboxHealth{Error: truncate(err.Error(), 255)}
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, sandboxHealthBodyLimit))
+
+	health := models.SandboxHealth{
+		Up:      resp.StatusCode >= 200 && resp.StatusCode < 300,
+		Ms:      int(time.Since(start).Milliseconds()),
+		Version: healthVersion(resp.Header, body),
+	}
+	if !health.Up {
+		health.Error = fmt.Sprintf("HTTP %d", resp.StatusCode)
+	}
+	return health
+}
+
+func healthVersion(header http.Header, body []byte) string {
+	var payload struct {
+		Version string `json:"version"`
+	}
+	version := ""
+	if json.Unmarshal(body, &payload) == nil {
+		version = payload.Version
+	} else if text := strings.TrimSpace(string(body)); plainVersion.MatchString(text) {
+		version = text
+	}
+	if version == "" {
+		version = header.Get("X-Version")
+	}
+	return truncate(strings.TrimSpace(version), 64)
+}
+
+// truncate fit the text in a varchar column
+func truncate(text string, max int) string {
+	if len(text) <= max {
+		return text
+	}
+	return text[:max]
+}
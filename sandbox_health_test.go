@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHealthVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		body   string
+		want   string
+	}{
+		{"json version", "", `{"status":"ok","version":"1.4.2"}`, "1.4.2"},
+		{"json without version use the header", "2.0.0", `{"status":"ok"}`, "2.0.0"},
+		{"json number version is ignored", "", `{"version":3}`, ""},
+		{"plain version", "", "v1.4.2-rc.1\n", "v1.4.2-rc.1"},
+		{"plain text is not a version", "", "OK", ""},
+		{"plain text use the header", "1.4.2", "healthy since 3 days", "1.4.2"},
+		{"no body no header", "", "", ""},
+		{"long version is truncated", "", `{"version":"` + strings.Repeat("a", 100) + `"}`, strings.Repeat("a", 64)},
+	}
+
+	for _, test := range tests {
+		header := http.Header{}
+		if test.header != "" {
+			header.Set("X-Version", test.header)
+		}
+		if got := healthVersion(header, []byte(test.body)); got != test.want {
+			t.Errorf("%s: healthVersion = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCheckSandboxHealth(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		up      bool
+		version string
+		err     string
+	}{
+		{"up", http.StatusOK, `{"version":"1.4.2"}`, true, "1.4.2", ""},
+		{"no content is up", http.StatusNoContent, "", true, "", ""},
+		{"server error is down", http.StatusBadGateway, "bad gateway", false, "", "HTTP 502"},
+		{"not found is down", http.StatusNotFound, "", false, "", "HTTP 404"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.status)
+				w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			health := checkSandboxHealth(server.URL + "/health")
+			if health.Up != test.up || health.Version != test.version || health.Error != test.err {
+				t.Errorf("checkSandboxHealth = %+v, want up %v version %q error %q", health, test.up, test.version, test.err)
+			}
+		})
+	}
+
+	t.Run("unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+		if health := checkSandboxHealth(server.URL); health.Up || health.Error == "" {
+			t.Errorf("checkSandboxHealth of a closed server = %+v, want down with the error", health)
+		}
+	})
+	t.Run("invalid url", func(t *testing.T) {
+		if health := checkSandboxHealth("http://sb3 .dev"); health.Up || health.Error != "invalid health url" {
+			t.Errorf("checkSandboxHealth of an invalid url = %+v, want the invalid url error", health)
+		}
+	})
+}
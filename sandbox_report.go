@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// sandboxReportPeriods is the window of each sandbox report period, counted back from now
+var sandboxReportPeriods = map[string]time.Duration{
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+}
+
+// sandboxUsage is the time a server or a user held the sandbox servers during the report window
+type sandboxUsage struct {
+	name   string
+	inUse  time.Duration
+	claims int
+}
+
+func handleSandboxReport(req *CommandRequest) slack.Attachment {
+	project := strings.ToLower(req.Args[1])
+	period := strings.ToLower(req.Args[2])
+	if period == "" {
+		period = "week"
+	}
+	location := UserLocation(req.User)
+
+	to := time.Now()
+	from := to.Add(-sandboxReportPeriods[period])
+
+	servers := map[string]*sandboxUsage{}
+	for _, server := range models.GetAllServers() {
+		if server.Project == project {
+			servers[server.ServerId] = &sandboxUsage{name: server.ServerId}
+		}
+	}
+	claims := models.GetClaimHistory(project, from, to)
+	if len(servers) == 0 && len(claims) == 0 {
+		return slack.Attachment{Text: fmt.Sprintf("Sorry <@%s>, project %s has no sandbox server.", req.User.ID, project), Color: "#e20228"}
+	}
+
+	users := map[string]*sandboxUsage{}
+	for _, claim := range claims {
+		// older bots opened the history on the waitlist offer, the reservation is not usage
+		if claim.Reason == sandboxOfferReason {
+			continue
+		}
+		inUse := overlap(claim.StartedAt, claim.EndedAt, from, to)
+		// a deleted server still count in the report
+		if servers[claim.ServerId] == nil {
+			servers[claim.ServerId] = &sandboxUsage{name: claim.ServerId + " (deleted)"}
+		}
+		if users[claim.SlackId] == nil {
+			users[claim.SlackId] = &sandboxUsage{name: claim.UserName}
+		}
+		for _, usage := range []*sandboxUsage{servers[claim.ServerId], users[claim.SlackId]} {
+			usage.inUse += inUse
+			usage.claims++
+		}
+	}
+
+	window := to.Sub(from)
+	var serverLines []string
+	for _, usage := range sortedUsage(servers) {
+		serverLines = append(serverLines, fmt.Sprintf(" *%s*: %s in use (%d%%), %d claims", usage.name, formatHours(usage.inUse), int(100*usage.inUse/window), usage.claims))
+	}
+	var userLines []string
+	for _, usage := range sortedUsage(users) {
+		userLines = append(userLines, fmt.Sprintf(" %s: %s, %d claims", usage.name, formatHours(usage.inUse), usage.claims))
+	}
+	if len(userLines) == 0 {
+		userLines = append(userLines, " Nobody claimed a server.")
+	}
+
+	text := fmt.Sprintf("Gotcha <@%s>, this is the sandbox usage of project %s for the last %s, since %s: \n\n*Servers*\n%s\n\n*Users*\n%s\n\n*Waitlist*\n %s",
+		req.User.ID, project, period, models.FormatTime(int(from.Unix()), location), strings.Join(serverLines, "\n"), strings.Join(userLines, "\n"), waitlistReport(models.GetWaitlistHistory(project, from, to), from, to))
+
+	return slack.Attachment{Text: text, Footer: "Only the claims made from the bot are counted, a waitlist offer once it is accepted."}
+}
+
+// waitlistReport summarize how many people waited, the longest queue and the wait times between from and to
+func waitlistReport(entries []models.SandboxWaitlist, from time.Time, to time.Time) string {
+	if len(entries) == 0 {
+		return "Nobody waited."
+	}
+
+	type event struct {
+		at    int
+		delta int
+	}
+	var events []event
+	var total, longest time.Duration
+	for _, entry := range entries {
+		// like the claims, only the part of the wait inside the window count
+		wait := overlap(entry.CreatedAt, entry.ClosedAt, from, to)
+		total += wait
+		if wait > longest {
+			longest = wait
+		}
+		createdAt := int(from.Unix())
+		if entry.CreatedAt > createdAt {
+			createdAt = entry.CreatedAt
+		}
+		events = append(events, event{createdAt, 1}, event{createdAt + int(wait/time.Second), -1})
+	}
+
+	// the leaving entry go first so back to back entries are not counted together
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].at == events[j].at {
+			return events[i].delta < events[j].delta
+		}
+		return events[i].at < events[j].at
+	})
+	length, peak := 0, 0
+	for _, e := range events {
+		length += e.delta
+		if length > peak {
+			peak = length
+		}
+	}
+
+	return fmt.Sprintf("%d people waited, at most %d at once, average wait %s, longest wait %s",
+		len(entries), peak, (total / time.Duration(len(entries))).Round(time.Minute), longest.Round(time.Minute))
+}
+
+// overlap is how long a claim from startedAt to endedAt, 0 while open, fall between from and to
+func overlap(startedAt int, endedAt int, from time.Time, to time.Time) time.Duration {
+	start := time.Unix(int64(startedAt), 0)
+	end := to
+	if endedAt > 0 && time.Unix(int64(endedAt), 0).Before(to) {
+		end = time.Unix(int64(endedAt), 0)
+	}
+	if start.Before(from) {
+		start = from
+	}
+	if end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// sortedUsage list the most used first
+func sortedUsage(usages map[string]*sandboxUsage) []*sandboxUsage {
+	var sorted []*sandboxUsage
+	for _, usage := range usages {
+		sorted = append(sorted, usage)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].inUse == sorted[j].inUse {
+			return sorted[i].name < sorted[j].name
+		}
+		return sorted[i].inUse > sorted[j].inUse
+	})
+	return sorted
+}
+
+func formatHours(duration time.Duration) string {
+	return fmt.Sprintf("%.1fh", duration.Hours())
+}
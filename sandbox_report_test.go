@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+func TestWaitlistReport(t *testing.T) {
+	to := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	from := to.Add(-7 * 24 * time.Hour)
+	at := func(offset time.Duration) int { return int(from.Add(offset).Unix()) }
+
+	tests := []struct {
+		name    string
+		entries []models.SandboxWaitlist
+		want    string
+	}{
+		{"nobody", nil, "Nobody waited."},
+		{"inside the window", []models.SandboxWaitlist{
+			{CreatedAt: at(time.Hour), ClosedAt: at(2 * time.Hour)},
+			{CreatedAt: at(90 * time.Minute), ClosedAt: at(4 * time.Hour)},
+		}, "2 people waited, at most 2 at once, average wait 1h45m0s, longest wait 2h30m0s"},
+		{"back to back are not together", []models.SandboxWaitlist{
+			{CreatedAt: at(time.Hour), ClosedAt: at(2 * time.Hour)},
+			{CreatedAt: at(2 * time.Hour), ClosedAt: at(3 * time.Hour)},
+		}, "2 people waited, at most 1 at once, average wait 1h0m0s, longest wait 1h0m0s"},
+		{"started before the window", []models.SandboxWaitlist{
+			{CreatedAt: at(-48 * time.Hour), ClosedAt: at(time.Hour)},
+		}, "1 people waited, at most 1 at once, average wait 1h0m0s, longest wait 1h0m0s"},
+		{"still waiting at the end of the window", []models.SandboxWaitlist{
+			{CreatedAt: at(7*24*time.Hour - 30*time.Minute), Status: models.WaitlistWaiting},
+		}, "1 people waited, at most 1 at once, average wait 30m0s, longest wait 30m0s"},
+		{"closed after the window", []models.SandboxWaitlist{
+			{CreatedAt: at(7*24*time.Hour - 30*time.Minute), ClosedAt: at(8 * 24 * time.Hour)},
+			{CreatedAt: at(-time.Hour), ClosedAt: at(7*24*time.Hour - 15*time.Minute)},
+		}, "2 people waited, at most 2 at once, average wait 84h8m0s, longest wait 167h45m0s"},
+	}
+
+	for _, test := range tests {
+		if got := waitlistReport(test.entries, from, to); got != test.want {
+			t.Errorf("%s: waitlistReport = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// sandboxOfferWindow is how long the next person in the waitlist has to accept a freed server
+const sandboxOfferWindow = 10 * time.Minute
+
+// sandboxOfferReason is the claim reason of a server reserved for a waitlist offer
+const sandboxOfferReason = "offered from the waitlist"
+
+// block action id of the waitlist offer buttons
+const (
+	actionSandboxOfferAccept = "sandbox_offer_accept"
+	actionSandboxOfferPass   = "sandbox_offer_pass"
+)
+
+// releaseSandboxServer free the server and offer it to the project waitlist,
+// an open offer of the server is closed since its holder gave it back
+func releaseSandboxServer(client *slack.Client, project string, serverId string, name string) {
+	freeSandboxServer(client, project, serverId, name)
+	offerSandboxServer(client, project, serverId)
+}
+
+// freeSandboxServer is releaseSandboxServer without offering the server, for an admin that claim it right away
+func freeSandboxServer(client *slack.Client, project string, serverId string, name string) {
+	if offer, ok := models.GetWaitlistOffer(project, serverId); ok && models.CloseWaitlistOffer(offer.Id, models.WaitlistPassed) {
+		if err := updateSandboxOffer(client, offer, "The server was released."); err != nil {
+			log.Println(err.Error())
+		}
+	}
+	models.UpdateServerStatus(project, serverId, name)
+}
+
+// releaseOfferedServer free the server of a closed offer, unless it is not reserved for the entry anymore
+func releaseOfferedServer(client *slack.Client, entry models.SandboxWaitlist) {
+	server, ok := models.GetServer(entry.Project, entry.ServerId)
+	if !ok || !server.Status || server.ClaimedById != entry.SlackId {
+		return
+	}
+	releaseSandboxServer(client, entry.Project, entry.ServerId, models.BotActor)
+}
+
+// offerSandboxServer reserve a free server for the oldest waiting user and DM them,
+// users that cannot be reached are skipped so the server does not stay reserved for nothing
+func offerSandboxServer(client *slack.Client, project string, serverId string) {
+	tried := map[string]bool{}
+	for {
+		entry, ok := models.GetNextWaiting(project)
+		if !ok || tried[entry.Id] {
+			// the entry is still waiting after a failed update, it keep its place for the next free server
+			return
+		}
+		tried[entry.Id] = true
+
+		expiresAt := time.Now().Add(sandboxOfferWindow)
+		if !models.OfferWaitlist(entry.Id, serverId, expiresAt) {
+			// someone else offered this entry a server already
+			continue
+		}
+		// only reserved, the claim history start if the offer is accepted
+		if !models.ReserveServer(project, serverId, entry.SlackId, entry.UserName, sandboxOfferReason, expiresAt) {
+			// the server was claimed in between, the entry go back to waiting and keep its place
+			log.Println("sandbox server", project, serverId, "was claimed before the waitlist offer")
+			models.CloseWaitlistOffer(entry.Id, models.WaitlistWaiting)
+			return
+		}
+
+		entry.ServerId = serverId
+		entry.OfferExpiresAt = int(expiresAt.Unix())
+		channel, ts, err := client.PostMessage(entry.SlackId,
+			slack.MsgOptionText(fmt.Sprintf("Sandbox server %s of %s is free for you", serverId, project), false),
+			slack.MsgOptionBlocks(sandboxOfferBlocks(entry, "", true)...),
+		)
+		if err != nil {
+			log.Println("failed to offer sandbox server", entry.SlackId, err.Error())
+			models.CloseWaitlistOffer(entry.Id, models.WaitlistExpired)
+			models.UpdateServerStatus(project, serverId, models.BotActor)
+			// try the next person for the same server
+			continue
+		}
+		models.SetWaitlistOfferMessage(entry.Id, channel, ts)
+		return
+	}
+}
+
+func sandboxOfferBlocks(entry models.SandboxWaitlist, outcome string, withActions bool) []slack.Block {
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("Good news <@%s>, sandbox server *%s* of *%s* is free and you are next in the waitlist :tada:", entry.SlackId, entry.ServerId, entry.Project), false, false), nil, nil),
+	}
+
+	if withActions {
+		blocks = append(blocks,
+			slack.NewActionBlock("sandbox_offer",
+				slack.NewButtonBlockElement(actionSandboxOfferAccept, entry.Id, slack.NewTextBlockObject(slack.PlainTextType, "Take it", false, false)).WithStyle(slack.StylePrimary),
+				slack.NewButtonBlockElement(actionSandboxOfferPass, entry.Id, slack.NewTextBlockObject(slack.PlainTextType, "Pass", false, false)),
+			),
+			slack.NewContextBlock("",
+				slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("The server is kept for you for %s, then it goes to the next person.", sandboxOfferWindow), false, false),
+			),
+		)
+	}
+
+	if outcome != "" {
+		blocks = append(blocks, slack.NewContextBlock("",
+			slack.NewTextBlockObject(slack.MarkdownType, outcome, false, false),
+		))
+	}
+	return blocks
+}
+
+// handleSandboxOffer answer the Take it or Pass click on a waitlist offer
+func handleSandboxOffer(callback slack.InteractionCallback, action *slack.BlockAction, client *slack.Client) error {
+	entry, ok := models.GetWaitlistEntry(action.Value)
+	if !ok || entry.SlackId != callback.User.ID {
+		return nil
+	}
+
+	if action.ActionID == actionSandboxOfferPass {
+		if !models.CloseWaitlistOffer(entry.Id, models.WaitlistPassed) {
+			return updateSandboxOffer(client, entry, "This offer is already closed.")
+		}
+		releaseOfferedServer(client, entry)
+		return updateSandboxOffer(client, entry, ":wave: You passed, the server went to the next person.")
+	}
+
+	if !models.CloseWaitlistOffer(entry.Id, models.WaitlistAccepted) {
+		return updateSandboxOffer(client, entry, "Sorry, this offer is expired and the server went to the next person.")
+	}
+
+	until := time.Now().Add(sandboxClaimDefault)
+	if !models.ClaimServer(entry.Project, entry.ServerId, entry.SlackId, entry.UserName, "from the waitlist", until) {
+		return updateSandboxOffer(client, entry, "Sorry, the server was taken back by an admin.")
+	}
+
+	location := DefaultLocation()
+	if user, err := client.GetUserInfo(entry.SlackId); err == nil {
+		location = UserLocation(user)
+	}
+	return updateSandboxOffer(client, entry, fmt.Sprintf(":white_check_mark: The server is yours until %s, use `release sandbox server %s,%s` once you are done.", models.FormatTime(int(until.Unix()), location), entry.Project, entry.ServerId))
+}
+
+// expireSandboxOffers pass the servers nobody accepted in time to the next person
+func expireSandboxOffers(client *slack.Client, now time.Time) {
+	for _, entry := range models.GetExpiredOffers(now) {
+		if !models.CloseWaitlistOffer(entry.Id, models.WaitlistExpired) {
+			continue
+		}
+		log.Println("Expired sandbox offer", entry.Project, entry.ServerId, entry.SlackId)
+		auditBot("expire sandbox offer", fmt.Sprintf("%s,%s to %s", entry.Project, entry.ServerId, entry.SlackId))
+		if err := updateSandboxOffer(client, entry, ":hourglass: You did not answer in time, the server went to the next person."); err != nil {
+			log.Println(err.Error())
+		}
+		releaseOfferedServer(client, entry)
+	}
+}
+
+func updateSandboxOffer(client *slack.Client, entry models.SandboxWaitlist, outcome string) error {
+	if entry.OfferChannel == "" {
+		return nil
+	}
+	_, _, _, err := client.UpdateMessage(entry.OfferChannel, entry.OfferTs,
+		slack.MsgOptionText(fmt.Sprintf("Sandbox server %s of %s", entry.ServerId, entry.Project), false),
+		slack.MsgOptionBlocks(sandboxOfferBlocks(entry, outcome, false)...),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update sandbox offer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// fakeSandbox keep the sandbox servers, the waitlist and the claim history of project grip in memory
+// and answer the model queries of the waitlist flow
+type fakeSandbox struct {
+	mu       sync.Mutex
+	servers  map[string]*models.TestingStatus
+	waitlist []*models.SandboxWaitlist
+	history  []*models.SandboxClaimHistory
+	// offerErr fail every OfferWaitlist update
+	offerErr error
+	// reminded is the server ids whose holder was reminded of the claim expiry
+	reminded map[string]bool
+}
+
+func newFakeSandbox(serverId string, waiting ...string) *fakeSandbox {
+	sandbox := &fakeSandbox{servers: map[string]*models.TestingStatus{
+		serverId: {Project: "grip", ServerId: serverId, Enabled: true},
+	}, reminded: map[string]bool{}}
+	for i, slackId := range waiting {
+		sandbox.waitlist = append(sandbox.waitlist, &models.SandboxWaitlist{
+			Id: "w-" + slackId, Project: "grip", SlackId: slackId, UserName: strings.ToLower(slackId), Status: models.WaitlistWaiting, CreatedAt: 1000 + i,
+		})
+	}
+	return sandbox
+}
+
+func (f *fakeSandbox) entry(slackId string) *models.SandboxWaitlist {
+	for _, entry := range f.waitlist {
+		if entry.SlackId == slackId {
+			return entry
+		}
+	}
+	return nil
+}
+
+func waitlistRow(w *models.SandboxWaitlist) []driver.Value {
+	return []driver.Value{w.Id, w.Project, w.SlackId, w.UserName, w.Status, w.ServerId, int64(w.OfferExpiresAt), w.OfferChannel, w.OfferTs, int64(w.CreatedAt), int64(w.ClosedAt)}
+}
+
+func serverRow(t *models.TestingStatus) []driver.Value {
+	return []driver.Value{t.Project, t.ServerId, t.LastBuildBy, int64(t.LastBuildOn), t.Status, t.StatusChangedBy, int64(t.StatusChangedOn), t.LastFeBranch, t.LastBeBranch, t.ClaimedById, t.ClaimReason, int64(t.ClaimUntil),
+		t.Enabled, t.URL, t.Description, t.OwnerTeam, t.HealthURL, t.HealthUp, int64(t.HealthMs), t.HealthVersion, t.HealthError, int64(t.HealthCheckedAt)}
+}
+
+func (f *fakeSandbox) handle(query string, args []driver.Value) fakeResult {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	arg := func(i int) string { return fmt.Sprint(args[i]) }
+	changed := func(ok bool) fakeResult {
+		if ok {
+			return fakeResult{affected: 1}
+		}
+		return fakeResult{}
+	}
+
+	switch {
+	case strings.Contains(query, "from sandbox_waitlist where project = ? and status = ? order by created_at limit 1"):
+		for _, entry := range f.waitlist {
+			if entry.Status == models.WaitlistWaiting {
+				return fakeResult{rows: [][]driver.Value{waitlistRow(entry)}}
+			}
+		}
+	case strings.Contains(query, "from sandbox_waitlist where project = ? and server_id = ? and status = ?"):
+		for _, entry := range f.waitlist {
+			if entry.ServerId == arg(1) && entry.Status == arg(2) {
+				return fakeResult{rows: [][]driver.Value{waitlistRow(entry)}}
+			}
+		}
+	case strings.Contains(query, "from sandbox_waitlist where id = ?"):
+		for _, entry := range f.waitlist {
+			if entry.Id == arg(0) {
+				return fakeResult{rows: [][]driver.Value{waitlistRow(entry)}}
+			}
+		}
+	case strings.Contains(query, "FROM sandbox_waitlist WHERE status = ? AND offer_expires_at <= ?"):
+		var rows [][]driver.Value
+		for _, entry := range f.waitlist {
+			if entry.Status == arg(0) && int64(entry.OfferExpiresAt) <= args[1].(int64) {
+				rows = append(rows, waitlistRow(entry))
+			}
+		}
+		return fakeResult{rows: rows}
+	case strings.HasPrefix(query, "UPDATE sandbox_waitlist set status = ?, server_id = ?, offer_expires_at = ?"):
+		if f.offerErr != nil {
+			return fakeResult{err: f.offerErr}
+		}
+		for _, entry := range f.waitlist {
+			if entry.Id == arg(3) && entry.Status == arg(4) {
+				entry.Status, entry.ServerId, entry.OfferExpiresAt = arg(0), arg(1), int(args[2].(int64))
+				return changed(true)
+			}
+		}
+	case strings.HasPrefix(query, "UPDATE sandbox_waitlist set status = ?, closed_at = ?"):
+		for _, entry := range f.waitlist {
+			if entry.Id == arg(2) && entry.Status == arg(3) {
+				entry.Status, entry.ClosedAt = arg(0), int(args[1].(int64))
+				return changed(true)
+			}
+		}
+	case strings.HasPrefix(query, "UPDATE sandbox_waitlist set offer_channel = ?"):
+		for _, entry := range f.waitlist {
+			if entry.Id == arg(2) {
+				entry.OfferChannel, entry.OfferTs = arg(0), arg(1)
+			}
+		}
+	case strings.HasPrefix(query, "SELECT project, server_id") && strings.Contains(query, "where project = ? and server_id = ?"):
+		if server := f.servers[arg(1)]; server != nil {
+			return fakeResult{rows: [][]driver.Value{serverRow(server)}}
+		}
+	case strings.HasPrefix(query, "UPDATE testing_status set status = 1"):
+		server := f.servers[arg(6)]
+		ok := server != nil && server.Enabled && (!server.Status || server.ClaimedById == arg(7))
+		if ok {
+			server.Status, server.StatusChangedBy, server.ClaimedById, server.ClaimReason, server.ClaimUntil = true, arg(0), arg(2), arg(3), int(args[4].(int64))
+		}
+		return changed(ok)
+	case strings.Contains(query, "FROM testing_status WHERE status = 1 AND claimed_by_id != '' AND claim_until > 0 AND claim_until <= ?"):
+		var rows [][]driver.Value
+		for _, server := range f.servers {
+			if server.Status && server.ClaimedById != "" && server.ClaimUntil > 0 && int64(server.ClaimUntil) <= args[0].(int64) &&
+				!(strings.Contains(query, "claim_reminded_at = 0") && f.reminded[server.ServerId]) {
+				rows = append(rows, serverRow(server))
+			}
+		}
+		return fakeResult{rows: rows}
+	case strings.HasPrefix(query, "UPDATE testing_status set claim_reminded_at = ?"):
+		f.reminded[arg(2)] = true
+	case strings.HasPrefix(query, "UPDATE testing_status set claim_until = ?"):
+		server := f.servers[arg(2)]
+		ok := server != nil && server.Status && server.ClaimedById == arg(3)
+		if ok {
+			server.ClaimUntil = int(args[0].(int64))
+			f.reminded[arg(2)] = false
+		}
+		return changed(ok)
+	case strings.HasPrefix(query, "UPDATE testing_status set status = 0") && strings.HasSuffix(query, "and claim_until = ?"):
+		server := f.servers[arg(3)]
+		ok := server != nil && server.Status && int64(server.ClaimUntil) == args[4].(int64)
+		if ok {
+			server.Status, server.StatusChangedBy, server.ClaimedById, server.ClaimReason, server.ClaimUntil = false, arg(0), "", "", 0
+			f.reminded[arg(3)] = false
+		}
+		return changed(ok)
+	case strings.HasPrefix(query, "UPDATE testing_status set status = 0"):
+		if server := f.servers[arg(3)]; server != nil {
+			server.Status, server.StatusChangedBy, server.ClaimedById, server.ClaimReason, server.ClaimUntil = false, arg(0), "", "", 0
+			return changed(true)
+		}
+	case strings.HasPrefix(query, "INSERT INTO sandbox_claim_history"):
+		for _, claim := range f.history {
+			if claim.ServerId == arg(2) && claim.EndedAt == 0 {
+				return fakeResult{}
+			}
+		}
+		f.history = append(f.history, &models.SandboxClaimHistory{Id: arg(0), Project: arg(1), ServerId: arg(2), SlackId: arg(3), UserName: arg(4), Reason: arg(5), StartedAt: int(args[6].(int64))})
+		return changed(true)
+	case strings.HasPrefix(query, "UPDATE sandbox_claim_history set ended_at = ?"):
+		for _, claim := range f.history {
+			if claim.ServerId == arg(3) && claim.EndedAt == 0 {
+				claim.EndedAt, claim.EndedBy = int(args[0].(int64)), arg(1)
+			}
+		}
+	}
+	return fakeResult{}
+}
+
+// fakeSlack answer the Slack Web API calls of the waitlist flow, the direct messages to unreachable fail
+type fakeSlack struct {
+	mu          sync.Mutex
+	unreachable map[string]bool
+	messages    []string
+	updates     []string
+}
+
+func (f *fakeSlack) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/chat.postMessage":
+		channel := r.Form.Get("channel")
+		if f.unreachable[channel] {
+			fmt.Fprint(w, `{"ok":false,"error":"cannot_dm_bot"}`)
+			return
+		}
+		f.messages = append(f.messages, channel)
+		fmt.Fprintf(w, `{"ok":true,"channel":"D%s","ts":"1700000000.000100"}`, channel)
+	case "/chat.update":
+		f.updates = append(f.updates, r.Form.Get("blocks"))
+		fmt.Fprintf(w, `{"ok":true,"channel":%q,"ts":%q}`, r.Form.Get("channel"), r.Form.Get("ts"))
+	case "/users.info":
+		fmt.Fprintf(w, `{"ok":true,"user":{"id":%q,"name":"someone","tz":"Asia/Jakarta"}}`, r.Form.Get("user"))
+	default:
+		fmt.Fprint(w, `{"ok":false,"error":"unknown_method"}`)
+	}
+}
+
+func (f *fakeSlack) dms() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.messages...)
+}
+
+func newFakeSlackClient(t *testing.T, unreachable ...string) (*slack.Client, *fakeSlack) {
+	fake := &fakeSlack{unreachable: map[string]bool{}}
+	for _, slackId := range unreachable {
+		fake.unreachable[slackId] = true
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return slack.New("xoxb-test", slack.OptionAPIURL(server.URL+"/")), fake
+}
+
+func offerAction(sandbox *fakeSandbox, slackId string, actionId string) (slack.InteractionCallback, *slack.BlockAction) {
+	callback := slack.InteractionCallback{User: slack.User{ID: slackId}}
+	return callback, &slack.BlockAction{ActionID: actionId, Value: sandbox.entry(slackId).Id}
+}
+
+func TestSandboxWaitlistOfferExpireAccept(t *testing.T) {
+	sandbox := newFakeSandbox("sb1", "U0ALICE", "U0BOB")
+	useFakeDB(t, sandbox.handle)
+	client, slackAPI := newFakeSlackClient(t)
+
+	offerSandboxServer(client, "grip", "sb1")
+	server := sandbox.servers["sb1"]
+	if alice := sandbox.entry("U0ALICE"); alice.Status != models.WaitlistOffered || alice.ServerId != "sb1" || alice.OfferChannel != "DU0ALICE" {
+		t.Fatalf("alice after the offer = %+v, want offered sb1 with the offer message", alice)
+	}
+	if !server.Status || server.ClaimedById != "U0ALICE" || server.ClaimReason != sandboxOfferReason {
+		t.Errorf("server after the offer = %+v, want reserved for alice", server)
+	}
+	if len(sandbox.history) != 0 {
+		t.Errorf("an offer opened the claim history: %+v", sandbox.history[0])
+	}
+
+	// nobody answer, the offer expire and the server go to bob
+	expireSandboxOffers(client, time.Now().Add(sandboxOfferWindow+time.Minute))
+	if alice := sandbox.entry("U0ALICE"); alice.Status != models.WaitlistExpired || alice.ClosedAt == 0 {
+		t.Errorf("alice after the expiry = %+v, want expired", alice)
+	}
+	if bob := sandbox.entry("U0BOB"); bob.Status != models.WaitlistOffered || bob.ServerId != "sb1" {
+		t.Fatalf("bob after the expiry = %+v, want offered sb1", bob)
+	}
+	if server.ClaimedById != "U0BOB" {
+		t.Errorf("server after the expiry is held by %q, want bob", server.ClaimedById)
+	}
+	if dms := slackAPI.dms(); strings.Join(dms, ",") != "U0ALICE,U0BOB" {
+		t.Errorf("offers sent to %v, want alice then bob", dms)
+	}
+
+	// alice is too late
+	callback, action := offerAction(sandbox, "U0ALICE", actionSandboxOfferAccept)
+	if err := handleSandboxOffer(callback, action, client); err != nil {
+		t.Fatalf("late accept error: %s", err.Error())
+	}
+	if server.ClaimedById != "U0BOB" {
+		t.Errorf("a late accept took the server from bob")
+	}
+
+	callback, action = offerAction(sandbox, "U0BOB", actionSandboxOfferAccept)
+	if err := handleSandboxOffer(callback, action, client); err != nil {
+		t.Fatalf("accept error: %s", err.Error())
+	}
+	if bob := sandbox.entry("U0BOB"); bob.Status != models.WaitlistAccepted {
+		t.Errorf("bob after accepting = %+v, want accepted", bob)
+	}
+	if !server.Status || server.ClaimedById != "U0BOB" || server.ClaimReason != "from the waitlist" || server.ClaimUntil == 0 {
+		t.Errorf("server after the accept = %+v, want claimed by bob", server)
+	}
+	if len(sandbox.history) != 1 || sandbox.history[0].SlackId != "U0BOB" || sandbox.history[0].EndedAt != 0 {
+		t.Errorf("claim history = %+v, want only the open claim of bob", sandbox.history)
+	}
+}
+
+func TestSandboxWaitlistPass(t *testing.T) {
+	sandbox := newFakeSandbox("sb1", "U0ALICE")
+	useFakeDB(t, sandbox.handle)
+	client, _ := newFakeSlackClient(t)
+
+	offerSandboxServer(client, "grip", "sb1")
+	callback, action := offerAction(sandbox, "U0ALICE", actionSandboxOfferPass)
+	if err := handleSandboxOffer(callback, action, client); err != nil {
+		t.Fatalf("pass error: %s", err.Error())
+	}
+
+	if alice := sandbox.entry("U0ALICE"); alice.Status != models.WaitlistPassed {
+		t.Errorf("alice after passing = %+v, want passed", alice)
+	}
+	if server := sandbox.servers["sb1"]; server.Status || server.ClaimedById != "" {
+		t.Errorf("server after the pass = %+v, want free", server)
+	}
+	if len(sandbox.history) != 0 {
+		t.Errorf("a passed offer is in the claim history: %+v", sandbox.history[0])
+	}
+}
+
+func TestSandboxWaitlistSkipUnreachable(t *testing.T) {
+	sandbox := newFakeSandbox("sb1", "U0ALICE", "U0BOB")
+	useFakeDB(t, sandbox.handle)
+	client, _ := newFakeSlackClient(t, "U0ALICE")
+
+	offerSandboxServer(client, "grip", "sb1")
+	if alice := sandbox.entry("U0ALICE"); alice.Status != models.WaitlistExpired {
+		t.Errorf("unreachable alice = %+v, want expired", alice)
+	}
+	if bob := sandbox.entry("U0BOB"); bob.Status != models.WaitlistOffered {
+		t.Errorf("bob = %+v, want offered", bob)
+	}
+	if server := sandbox.servers["sb1"]; server.ClaimedById != "U0BOB" {
+		t.Errorf("server is held by %q, want bob", server.ClaimedById)
+	}
+}
+
+func TestSandboxWaitlistOfferUpdateFailed(t *testing.T) {
+	sandbox := newFakeSandbox("sb1", "U0ALICE", "U0BOB")
+	sandbox.offerErr = errors.New("lost connection")
+	useFakeDB(t, sandbox.handle)
+	client, slackAPI := newFakeSlackClient(t)
+
+	done := make(chan struct{})
+	go func() {
+		offerSandboxServer(client, "grip", "sb1")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("offerSandboxServer keep retrying the same entry")
+	}
+
+	if alice := sandbox.entry("U0ALICE"); alice.Status != models.WaitlistWaiting {
+		t.Errorf("alice = %+v, want still waiting", alice)
+	}
+	if server := sandbox.servers["sb1"]; server.Status || len(slackAPI.dms()) != 0 {
+		t.Errorf("server = %+v with %d offers sent, want free and no offer", server, len(slackAPI.dms()))
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clock layouts accepted for the time of day part, checked in order
+var clockLayouts = []string{"15:04", "3:04PM", "3:04 PM", "3PM", "3 PM"}
+
+// date layouts accepted for the date part of an absolute schedule
+var dateLayouts = []string{"2006-01-02", "2006/01/02", "02-01-2006", "02/01/2006"}
+
+var relativeRegex = regexp.MustCompile(`^in\s+(\d+)\s*(m|min|mins|minute|minutes|h|hr|hrs|hour|hours|d|day|days)$`)
+
+// ParseReleaseTime turn the schedule input into an absolute time in now's location.
+//
+// Accepted inputs:
+//   - "2026-10-20 21:00", "2026-10-20 09:00PM"
+//   - "in 30m", "in 2h", "in 1 day"
+//   - "today 21:00", "tomorrow 9pm", "friday 18:00", "next friday 6pm"
+//   - "21:00", "09:00PM", "9pm", a clock time that already passed today means tomorrow
+func ParseReleaseTime(input string, now time.Time) (time.Time, error) {
+	text := strings.ToLower(strings.Join(strings.Fields(input), " "))
+	text = strings.TrimPrefix(text, "at ")
+	if text == "" {
+		return time.Time{}, errors.New("release time is empty")
+	}
+
+	if match := relativeRegex.FindStringSubmatch(text); match != nil {
+		amount, err := strconv.Atoi(match[1])
+		if err != nil || amount <= 0 {
+			return time.Time{}, fmt.Errorf("invalid duration %q", match[1])
+		}
+		unit := time.Minute
+		switch match[2][0] {
+		case 'h':
+			unit = time.Hour
+		case 'd':
+			unit = 24 * time.Hour
+		}
+		return now.Add(time.Duration(amount) * unit).Truncate(time.Minute), nil
+	}
+
+	day, clock := splitDay(text)
+
+	hour, minute, err := parseClock(clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if day == "" {
+		result := atClock(now, hour, minute)
+		if !result.After(now) {
+			result = atClock(now.AddDate(0, 0, 1), hour, minute)
+		}
+		return result, nil
+	}
+
+	if day == "today" || day == "tomorrow" {
+		date := now
+		if day == "tomorrow" {
+			date = now.AddDate(0, 0, 1)
+		}
+		result := atClock(date, hour, minute)
+		if !result.After(now) {
+			return time.Time{}, fmt.Errorf("%s is already passed", result.Format("2006-01-02 15:04"))
+		}
+		return result, nil
+	}
+
+	if weekday, next, ok := parseWeekday(day); ok {
+		diff := (int(weekday) - int(now.Weekday()) + 7) % 7
+		result := atClock(now.AddDate(0, 0, diff), hour, minute)
+		if !result.After(now) || (next && diff == 0) {
+			result = atClock(now.AddDate(0, 0, diff+7), hour, minute)
+		}
+		return result, nil
+	}
+
+	for _, layout := range dateLayouts {
+		date, err := time.ParseInLocation(layout, day, now.Location())
+		if err != nil {
+			continue
+		}
+		result := atClock(date, hour, minute)
+		if !result.After(now) {
+			return time.Time{}, fmt.Errorf("%s is already passed", result.Format("2006-01-02 15:04"))
+		}
+		return result, nil
+	}
+
+	return time.Time{}, fmt.Errorf("unknown date %q", day)
+}
+
+// splitDay separate the day part ("tomorrow", "next friday", "2026-10-20") from the clock part
+func splitDay(text string) (string, string) {
+	words := strings.Split(text, " ")
+	for i := len(words) - 1; i > 0; i-- {
+		if _, _, err := parseClock(strings.Join(words[i:], " ")); err == nil {
+			day := strings.Join(words[:i], " ")
+			day = strings.TrimSuffix(day, " at")
+			return day, strings.Join(words[i:], " ")
+		}
+	}
+	return "", text
+}
+
+func parseClock(clock string) (int, int, error) {
+	clock = strings.ToUpper(clock)
+	for _, layout := range clockLayouts {
+		parsed, err := time.Parse(layout, clock)
+		if err == nil {
+			return parsed.Hour(), parsed.Minute(), nil
+		}
+	}
+	return 0, 0, fmt.Errorf("unknown time %q, use hh:mm or hh:mma", strings.ToLower(clock))
+}
+
+func parseWeekday(day string) (time.Weekday, bool, bool) {
+	next := false
+	if strings.HasPrefix(day, "next ") {
+		next = true
+		day = strings.TrimPrefix(day, "next ")
+	}
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		name := strings.ToLower(weekday.String())
+		if day == name || day == name[:3] {
+			return weekday, next, true
+		}
+	}
+	return 0, false, false
+}
+
+// atClock is the time of day on the date of day, a time skipped when the clock move forward for DST
+// move forward by the gap too, e.g. 02:30 become 03:30, instead of the hour before time.Date give
+func atClock(day time.Time, hour int, minute int) time.Time {
+	result := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+	gap := ((hour*60+minute-result.Hour()*60-result.Minute())%(24*60) + 24*60) % (24 * 60)
+	if gap > 0 && gap < 12*60 {
+		result = result.Add(time.Duration(gap) * time.Minute)
+	}
+	return result
+}
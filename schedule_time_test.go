@@ -0,0 +1,139 @@
+package main
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParseReleaseTime(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*60*60)
+	// Wednesday
+	now := time.Date(2026, 10, 14, 15, 0, 0, 0, wib)
+	at := func(month time.Month, day int, hour int, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, wib)
+	}
+
+	tests := []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		// clock only, a time already passed today roll to tomorrow
+		{input: "21:00", want: at(10, 14, 21, 0)},
+		{input: "9pm", want: at(10, 14, 21, 0)},
+		{input: "09:00PM", want: at(10, 14, 21, 0)},
+		{input: "at 9 PM", want: at(10, 14, 21, 0)},
+		{input: "15:00", want: at(10, 15, 15, 0)},
+		{input: "09:00", want: at(10, 15, 9, 0)},
+		{input: "  14:59 ", want: at(10, 15, 14, 59)},
+
+		// relative
+		{input: "in 30m", want: at(10, 14, 15, 30)},
+		{input: "in 2 hours", want: at(10, 14, 17, 0)},
+		{input: "in 1 day", want: at(10, 15, 15, 0)},
+		{input: "in 0m", wantErr: true},
+
+		// today and tomorrow never roll over
+		{input: "today 21:00", want: at(10, 14, 21, 0)},
+		{input: "Today 09:00", wantErr: true},
+		{input: "tomorrow 9pm", want: at(10, 15, 21, 0)},
+		{input: "tomorrow at 08:30", want: at(10, 15, 8, 30)},
+
+		// weekdays
+		{input: "friday 18:00", want: at(10, 16, 18, 0)},
+		{input: "fri 6pm", want: at(10, 16, 18, 0)},
+		{input: "monday at 10:00", want: at(10, 19, 10, 0)},
+		{input: "wednesday 18:00", want: at(10, 14, 18, 0)},
+		{input: "wednesday 09:00", want: at(10, 21, 9, 0)},
+		{input: "next wednesday 18:00", want: at(10, 21, 18, 0)},
+		{input: "next friday 6pm", want: at(10, 16, 18, 0)},
+
+		// absolute dates
+		{input: "2026-10-20 21:00", want: at(10, 20, 21, 0)},
+		{input: "2026/10/20 09:00PM", want: at(10, 20, 21, 0)},
+		{input: "20/10/2026 9pm", want: at(10, 20, 21, 0)},
+		{input: "2026-10-01 10:00", wantErr: true},
+
+		// invalid
+		{input: "", wantErr: true},
+		{input: "25:00", wantErr: true},
+		{input: "someday 10:00", wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseReleaseTime(test.input, now)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ParseReleaseTime(%q) = %s, want an error", test.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseReleaseTime(%q) error: %s", test.input, err.Error())
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("ParseReleaseTime(%q) = %s, want %s", test.input, got, test.want)
+		}
+	}
+}
+
+func TestParseReleaseTimeDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		input   string
+		want    time.Time
+		elapsed time.Duration
+	}{
+		{
+			name:    "clock time keep the wall clock across spring forward",
+			now:     time.Date(2026, 3, 7, 12, 0, 0, 0, newYork),
+			input:   "tomorrow 09:00",
+			want:    time.Date(2026, 3, 8, 9, 0, 0, 0, newYork),
+			elapsed: 20 * time.Hour,
+		},
+		{
+			name:    "clock time keep the wall clock across fall back",
+			now:     time.Date(2026, 10, 31, 12, 0, 0, 0, newYork),
+			input:   "sunday 12:00",
+			want:    time.Date(2026, 11, 1, 12, 0, 0, 0, newYork),
+			elapsed: 25 * time.Hour,
+		},
+		{
+			name:    "a skipped time move forward",
+			now:     time.Date(2026, 3, 7, 12, 0, 0, 0, newYork),
+			input:   "tomorrow 02:30",
+			want:    time.Date(2026, 3, 8, 3, 30, 0, 0, newYork),
+			elapsed: 14*time.Hour + 30*time.Minute,
+		},
+		{
+			name:    "relative days are 24 hours",
+			now:     time.Date(2026, 3, 7, 12, 0, 0, 0, newYork),
+			input:   "in 1 day",
+			want:    time.Date(2026, 3, 8, 13, 0, 0, 0, newYork),
+			elapsed: 24 * time.Hour,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseReleaseTime(test.input, test.now)
+			if err != nil {
+				t.Fatalf("ParseReleaseTime(%q) error: %s", test.input, err.Error())
+			}
+			if !got.Equal(test.want) {
+				t.Errorf("ParseReleaseTime(%q) = %s, want %s", test.input, got, test.want)
+			}
+			if elapsed := got.Sub(test.now); elapsed != test.elapsed {
+				t.Errorf("ParseReleaseTime(%q) is %s after now, want %s", test.input, elapsed, test.elapsed)
+			}
+		})
+	}
+}
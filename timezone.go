@@ -0,0 +1,69 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/config"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// defaultTimezone is used when DEFAULT_TIMEZONE is not configured
+const defaultTimezone = "Asia/Singapore"
+
+// loadLocation return nil when the name is empty or not a valid IANA timezone
+func loadLocation(name string) *time.Location {
+	if name == "" {
+		return nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		log.Println("unknown timezone", name, err.Error())
+		return nil
+	}
+	return location
+}
+
+// DefaultLocation is the bot wide timezone from DEFAULT_TIMEZONE
+func DefaultLocation() *time.Location {
+	if location := loadLocation(config.GetConfig("DEFAULT_TIMEZONE")); location != nil {
+		return location
+	}
+	if location := loadLocation(defaultTimezone); location != nil {
+		return location
+	}
+	return time.UTC
+}
+
+// UserLocation resolve the timezone a user read time in: own preference, slack profile, then the default
+func UserLocation(user *slack.User) *time.Location {
+	if location := userOwnLocation(user); location != nil {
+		return location
+	}
+	return DefaultLocation()
+}
+
+// ScheduleLocation resolve the timezone a schedule input for project is interpreted in,
+// the project timezone is used when the user has neither a preference nor a slack timezone
+func ScheduleLocation(user *slack.User, project string) *time.Location {
+	if location := userOwnLocation(user); location != nil {
+		return location
+	}
+	return ProjectLocation(project)
+}
+
+// ProjectLocation is the project timezone or the default
+func ProjectLocation(project string) *time.Location {
+	if location := loadLocation(models.GetProjectTimezone(project)); location != nil {
+		return location
+	}
+	return DefaultLocation()
+}
+
+func userOwnLocation(user *slack.User) *time.Location {
+	if location := loadLocation(models.GetUserTimezone(user.ID)); location != nil {
+		return location
+	}
+	return loadLocation(user.TZ)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stevenfamy/go-slackbot-release/config"
+	"github.com/stevenfamy/go-slackbot-release/models"
+)
+
+// defaultWebhookAddr is used when WEBHOOK_ADDR is not configured
+const defaultWebhookAddr = ":8080"
+
+var slackUserId = regexp.MustCompile(`^[UW][A-Z0-9]{6,}$`)
+
+// sandboxBuildWebhook is the body Jenkins post after a sandbox build
+type sandboxBuildWebhook struct {
+	Project  string `json:"project"`
+	ServerId string `json:"server_id"`
+	// BuildBy is the name or the slack id of who started the build
+	BuildBy  string `json:"build_by"`
+	FeBranch string `json:"fe_branch"`
+	BeBranch string `json:"be_branch"`
+	BuildURL string `json:"build_url"`
+	// Result is the Jenkins build result, only SUCCESS record the branches on the server
+	Result string `json:"result"`
+}
+
+// succeeded tell if the build deployed the branches
+func (b sandboxBuildWebhook) succeeded() bool {
+	return strings.EqualFold(b.Result, "SUCCESS")
+}
+
+// startWebhookServer serve the inbound webhooks, it is disabled without WEBHOOK_SECRET
+// so the endpoint is never exposed without authentication
+func startWebhookServer(client *slack.Client) {
+	secret := config.GetConfig("WEBHOOK_SECRET")
+	if secret == "" {
+		log.Println("WEBHOOK_SECRET is not set, inbound webhooks are disabled")
+		return
+	}
+	addr := config.GetConfig("WEBHOOK_ADDR")
+	if addr == "" {
+		addr = defaultWebhookAddr
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/webhook/sandbox-build", requireWebhookSecret(secret, handleSandboxBuildWebhook(client)))
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		log.Println("webhook server listening on", addr)
+		if err := server.ListenAndServe(); err != nil {
+			log.Println("webhook server stopped", err.Error())
+		}
+	}()
+}
+
+// requireWebhookSecret accept the request only with the Authorization: Bearer <WEBHOOK_SECRET> header
+func requireWebhookSecret(secret string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authorization, "Bearer ") || subtle.ConstantTimeCompare([]byte(authorization[len("Bearer "):]), []byte(secret)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleSandboxBuildWebhook record a successful build on testing_status and post the build to the project channel,
+// a failed build is only reported since the server still run the branches of the last successful one
+func handleSandboxBuildWebhook(client *slack.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var build sandboxBuildWebhook
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&build); err != nil {
+			http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		build.Project = strings.ToLower(strings.TrimSpace(build.Project))
+		build.ServerId = strings.TrimSpace(build.ServerId)
+		build.Result = strings.ToUpper(strings.TrimSpace(build.Result))
+		if build.Project == "" || build.ServerId == "" || build.BuildBy == "" || build.Result == "" {
+			http.Error(w, "project, server_id, build_by and result are required", http.StatusBadRequest)
+			return
+		}
+
+		buildBy := build.BuildBy
+		if slackUserId.MatchString(build.BuildBy) {
+			// a slack id is stored with the same name the bot use for claims
+			if user, err := client.GetUserInfo(build.BuildBy); err == nil {
+				buildBy = sandboxUserName(user)
+			}
+		}
+		found := false
+		if build.succeeded() {
+			found = models.UpdateServerBuild(build.Project, build.ServerId, buildBy, build.FeBranch, build.BeBranch)
+		} else {
+			_, found = models.GetServer(build.Project, build.ServerId)
+		}
+		if !found {
+			http.Error(w, fmt.Sprintf("no sandbox server %s for project %s", build.ServerId, build.Project), http.StatusNotFound)
+			return
+		}
+		log.Println("Sandbox build", build.Project, build.ServerId, buildBy, build.FeBranch, build.BeBranch, build.Result)
+		outcome := models.AuditOk
+		if !build.succeeded() {
+			outcome = models.AuditFailed
+		}
+		models.AddAudit(models.AuditLog{ActorName: buildBy, Command: "webhook sandbox build", Arguments: fmt.Sprintf("%s,%s fe=%s be=%s %s %s", build.Project, build.ServerId, build.FeBranch, build.BeBranch, build.Result, build.BuildURL), Outcome: outcome})
+
+		notifySandboxBuild(client, build, buildBy)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// notifySandboxBuild post the build to the project channel, or SANDBOX_CHANNEL when the project has none
+func notifySandboxBuild(client *slack.Client, build sandboxBuildWebhook, buildBy string) {
+	channel := models.GetProjectChannel(build.Project)
+	if channel == "" {
+		channel = config.GetConfig("SANDBOX_CHANNEL")
+	}
+	if channel == "" {
+		return
+	}
+
+	if slackUserId.MatchString(build.BuildBy) {
+		buildBy = fmt.Sprintf("<@%s>", build.BuildBy)
+	}
+	attachment := slack.Attachment{
+		Text:   fmt.Sprintf("%s deployed FE *%s* and BE *%s* to sandbox server %s of %s", buildBy, orNone(build.FeBranch), orNone(build.BeBranch), build.ServerId, build.Project),
+		Color:  "#563a9b",
+		Footer: "GRIP Release Bot sandbox build",
+	}
+	if !build.succeeded() {
+		attachment.Text = fmt.Sprintf("Build of FE *%s* and BE *%s* by %s to sandbox server %s of %s ended with %s, the server keep its previous branches", orNone(build.FeBranch), orNone(build.BeBranch), buildBy, build.ServerId, build.Project, build.Result)
+		attachment.Color = "#e20228"
+	}
+	if build.BuildURL != "" {
+		attachment.Footer = build.BuildURL
+	}
+	if _, _, err := client.PostMessage(channel, slack.MsgOptionAttachments(attachment)); err != nil {
+		log.Println("failed to post sandbox build", channel, err.Error())
+	}
+}
+
+func orNone(branch string) string {
+	if branch == "" {
+		return "-"
+	}
+	return branch
+}
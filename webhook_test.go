@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequireWebhookSecret(t *testing.T) {
+	tests := []struct {
+		authorization string
+		want          int
+	}{
+		{"Bearer s3cret", http.StatusNoContent},
+		{"s3cret", http.StatusUnauthorized},
+		{"bearer s3cret", http.StatusUnauthorized},
+		{"Basic s3cret", http.StatusUnauthorized},
+		{"Bearer s3cret2", http.StatusUnauthorized},
+		{"Bearer ", http.StatusUnauthorized},
+		{"", http.StatusUnauthorized},
+	}
+
+	handler := requireWebhookSecret("s3cret", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/webhook/sandbox-build", nil)
+		if test.authorization != "" {
+			req.Header.Set("Authorization", test.authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != test.want {
+			t.Errorf("Authorization %q = %d, want %d", test.authorization, rec.Code, test.want)
+		}
+	}
+}
+
+func TestSandboxBuildWebhookRequiresResult(t *testing.T) {
+	body := `{"project":"grip","server_id":"sb1","build_by":"jenkins","fe_branch":"main"}`
+	req := httptest.NewRequest(http.MethodPost, "/webhook/sandbox-build", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handleSandboxBuildWebhook(nil).ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "result") {
+		t.Errorf("build without result = %d %q, want a bad request", rec.Code, rec.Body.String())
+	}
+}